@@ -8,10 +8,18 @@ import (
 	"fmt"
 
 	"github.com/juju/errors"
-	"github.com/perrito666/got/bug/work"
+	"github.com/perrito666/got/bug/defaultbase"
+	"github.com/perrito666/got/bug/fix"
+	"github.com/perrito666/got/bug/link"
+	"github.com/perrito666/got/bug/port"
 	"github.com/perrito666/got/cli"
 	"github.com/perrito666/got/git"
 	"github.com/perrito666/got/registry"
+	"github.com/perrito666/got/util"
+	"github.com/perrito666/got/workitem"
+	"github.com/perrito666/got/workitem/clean"
+	"github.com/perrito666/got/workitem/finish"
+	"github.com/perrito666/got/workitem/work"
 )
 
 var flagSet *flag.FlagSet
@@ -19,6 +27,14 @@ var flagSet *flag.FlagSet
 type config struct {
 	abbreviateList bool
 	interactive    bool
+	base           string
+	merge          bool
+	abort          bool
+	resume         bool
+	title          bool
+	slug           string
+
+	workitem.Flags
 }
 
 var callConfig = &config{}
@@ -32,6 +48,15 @@ func init() {
 	flagSet.BoolVar(&callConfig.abbreviateList, "s", false, shortDescription)
 	flagSet.BoolVar(&callConfig.abbreviateList, "short", false, shortDescription)
 	flagSet.BoolVar(&callConfig.interactive, "i", false, "prompt the bug with a menu.")
+	baseDescription := "the maintenance branch to use as base for the fix."
+	flagSet.StringVar(&callConfig.base, "b", "", baseDescription)
+	flagSet.StringVar(&callConfig.base, "base", "", baseDescription)
+	flagSet.BoolVar(&callConfig.merge, "m", false, "port the commit that merged the fix upstream.")
+	flagSet.BoolVar(&callConfig.abort, "abort", false, "undo a port that stopped on conflicts.")
+	flagSet.BoolVar(&callConfig.resume, "continue", false, "resume a port after solving its conflicts.")
+	flagSet.BoolVar(&callConfig.title, "title", false, "also print the bug title from the issue tracker.")
+	callConfig.Flags.Add(flagSet)
+	flagSet.StringVar(&callConfig.slug, "slug", "", "short description for branch names that carry one.")
 }
 
 // NewBugCommand is the constructor for the "bug" subcommand.
@@ -54,10 +79,16 @@ that same branch for you to work on, if not you will be prompted for a choice.
 
 bug available subcommands:
 
-  fix [-b base_branch] <bug id>
+  fix [-b base_branch] [-slug description] <bug id>
     will create a new branch to fix <bug id>, if base branch is provided it will
     be used as the base for the fix branch, otherwise there will be an interactive
     prompt.
+    branches are named fix_<base>_<bug id> unless a template is configured in
+    the got.fix.template (or got.branch.template) git config key or in the
+    .got.yml file of the repository, ie:
+      git config got.fix.template "bugfix/{target}/{id}-{slug}"
+    the placeholders are {type}, {target}, {id} and {slug}, when the template
+    has a {slug} it is taken from -slug, the bug title or prompted for.
 
   port [-m] [-b target_branch]
     will try to cherry pick the commits from this branch to the target if possible.
@@ -66,21 +97,51 @@ bug available subcommands:
       - will pull -r from the upstream branch.
       - will will be prompted to choose a commit to do the merge (all possible
       magic will be worked to try to do this, even if it is a github merge.
+    if the commits do not apply cleanly the port will stop so you can solve the
+    conflicts.
 
-  work [-s|--short]
+  port --continue
+    will resume a port once the conflicts are solved.
+
+  port --abort
+    will undo a port that stopped, removing the branch that was being created.
+
+  work [-s|--short] [-r|--remote]
+  list [-s|--short] [-r|--remote]
     will list the bugs you can work on and the branches for wich you can do it.
+    with -r the fix branches that only exist in remotes are listed too, marked
+    as local, remote or both.
 
-  work [-b base_branch] <number>
+  work [-r|--remote] [-b base_branch] <number>
     will checkout the branch for that bug, if base branch is specified it will
     try to checkout the fix branch for that maintenance branch oterwise the default
     specified will be checked out.
-
-  link
+    with -r a branch that only exists in a remote can be chosen, a local branch
+    tracking it is created.
+
+  finish [--strategy merge|squash|rebase] [--delete-remote] [--force] [-b base_branch] [bug id]
+    will merge the fix branch for the bug, or the current one, into its target
+    unless it already is, delete it and checkout the target.
+    the strategy can also be set in the got.fix.strategy git config key, merge
+    is used by default. with --delete-remote the branch is deleted from its
+    remote too. it refuses to run with uncommitted changes unless --force is given.
+
+  clean [--delete-remote]
+    will offer to delete the fix branches already merged into their targets,
+    squashed ones included, with --delete-remote they are deleted from their
+    remote too.
+
+  link [--title] [bug id]
     will print the link to the bug in the issue tracker: (current supported trackers
     are launchpad and github)
+    the bug is the one of the current fix branch unless one is given, with --title
+    its title will be fetched from the tracker too.
+    the tracker is inferred from the origin remote, it can be set with:
+      git config got.tracker launchpad|github|github:<owner>/<repo>
  
   default [base_work_branch]
     will set the given base branch as the default or prompt you for a new one.
+    the default is stored in the got.fix.default git config key of the repository.
 
 `
 
@@ -100,15 +161,75 @@ func (b *Command) Run(args []string) error {
 		return errors.Annotate(err, "error parsing arguments")
 	}
 	switch subC {
-	case "work":
+	case "work", "list":
 		w := work.Command{
+			Type:        util.FixType,
 			Args:        flagSet.Args(),
+			Base:        callConfig.base,
 			Interactive: callConfig.interactive,
 			Short:       callConfig.abbreviateList,
-			UI:          &cli.UI{},
-			NewGit:      git.New,
+			Remote:      callConfig.Remote,
+			UI:          cli.New(),
+			NewGit:      git.Backend,
+		}
+		if subC == "list" {
+			return w.List()
 		}
 		return w.Handle()
+	case "fix":
+		f := fix.Command{
+			Args:   flagSet.Args(),
+			Base:   callConfig.base,
+			Slug:   callConfig.slug,
+			UI:     cli.New(),
+			NewGit: git.Backend,
+		}
+		return f.Handle()
+	case "port":
+		p := port.Command{
+			Args:     flagSet.Args(),
+			Target:   callConfig.base,
+			Merge:    callConfig.merge,
+			Abort:    callConfig.abort,
+			Continue: callConfig.resume,
+			UI:       cli.New(),
+			NewGit:   git.Backend,
+		}
+		return p.Handle()
+	case "clean":
+		c := clean.Command{
+			Type:         util.FixType,
+			DeleteRemote: callConfig.DeleteRemote,
+			UI:           cli.New(),
+			NewGit:       git.Backend,
+		}
+		return c.Handle()
+	case "finish":
+		f := finish.Command{
+			Type:         util.FixType,
+			Args:         flagSet.Args(),
+			Base:         callConfig.base,
+			Strategy:     callConfig.Strategy,
+			DeleteRemote: callConfig.DeleteRemote,
+			Force:        callConfig.Force,
+			UI:           cli.New(),
+			NewGit:       git.Backend,
+		}
+		return f.Handle()
+	case "link":
+		l := link.Command{
+			Args:   flagSet.Args(),
+			Title:  callConfig.title,
+			NewGit: git.Backend,
+		}
+		return l.Handle()
+	case "default":
+		d := defaultbase.Command{
+			Args:   flagSet.Args(),
+			UI:     cli.New(),
+			NewGit: git.Backend,
+		}
+		return d.Handle()
 	}
-	return nil
+	return errors.NotSupportedf("%s sub-command %q", "bug", subC)
 }
@@ -0,0 +1,49 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package defaultbase
+
+import (
+	"github.com/juju/errors"
+	"github.com/perrito666/got/git"
+	"github.com/perrito666/got/interfaces"
+	"github.com/perrito666/got/util"
+)
+
+// Command holds the configuration and methods required for the default
+// sub-command.
+type Command struct {
+	Args   []string
+	UI     interfaces.UI
+	NewGit git.CompatibleConstructor
+}
+
+// Handle is the entry point for the Default sub-command.
+func (d *Command) Handle() error {
+	var base string
+	if len(d.Args) > 0 {
+		base = d.Args[0]
+		exists, err := git.NewRepo(d.NewGit).BranchExists(base)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !exists {
+			return errors.NotFoundf("branch %q", base)
+		}
+	} else {
+		var err error
+		if base, err = util.PickBase(d.NewGit, d.UI); err != nil {
+			return errors.Annotate(err, "could not select a default base branch")
+		}
+		// no base chosen, user most likely hit Esc.
+		if base == "" {
+			return nil
+		}
+	}
+
+	if err := util.SetDefaultBase(d.NewGit, util.FixType, base); err != nil {
+		return errors.Trace(err)
+	}
+	util.Reportf("new fixes will be based on %q \n", base)
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package defaultbase
+
+import (
+	"strings"
+	"testing"
+
+	gtesting "github.com/perrito666/got/testing"
+)
+
+func TestHandle(t *testing.T) {
+	tests := map[string]struct {
+		args      []string
+		responses map[string]gtesting.Response
+		choices   [][]int
+		// set is the git call storing the default, empty for none.
+		set   string
+		valid bool
+	}{
+		"given":   {args: []string{"1.2"}, set: "config got.fix.default 1.2", valid: true},
+		"unknown": {args: []string{"1.4"}, responses: map[string]gtesting.Response{"rev-parse refs/heads/1.4": {ExitCode: 1}}},
+		"picked":  {choices: [][]int{{1}}, set: "config got.fix.default 1.3", valid: true},
+		// nothing is chosen, as if the user hit Esc.
+		"cancelled": {valid: true},
+	}
+	for name, test := range tests {
+		script := &gtesting.ScriptedGit{}
+		script.AddBranches([]gtesting.Branch{{Name: "1.2", Current: true}, {Name: "1.3"}}, nil)
+		for key, response := range test.responses {
+			script.Responses[key] = response
+		}
+		c := Command{
+			Args:   test.args,
+			UI:     &gtesting.FakeUI{Choices: test.choices},
+			NewGit: script.New,
+		}
+		if err := c.Handle(); (err == nil) != test.valid {
+			t.Logf("%s: unexpected error: %v", name, err)
+			t.Fail()
+			continue
+		}
+		set := []string{}
+		for _, call := range script.CallsTo("config") {
+			if !strings.HasPrefix(call, "config --get") {
+				set = append(set, call)
+			}
+		}
+		if test.set == "" && len(set) != 0 || test.set != "" && (len(set) != 1 || set[0] != test.set) {
+			t.Logf("%s: expected %q got %q", name, test.set, set)
+			t.Fail()
+		}
+	}
+}
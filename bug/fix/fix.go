@@ -0,0 +1,104 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package fix
+
+import (
+	"github.com/juju/errors"
+	"github.com/perrito666/got/git"
+	"github.com/perrito666/got/interfaces"
+	"github.com/perrito666/got/tracker"
+	"github.com/perrito666/got/util"
+)
+
+// Command holds the configuration and methods required for the fix
+// sub-command.
+type Command struct {
+	Args   []string
+	Base   string
+	Slug   string
+	UI     interfaces.UI
+	NewGit git.CompatibleConstructor
+}
+
+// Handle is the entry point for the Fix sub-command.
+func (f *Command) Handle() error {
+	if len(f.Args) == 0 {
+		return errors.New("please specify the bug id to fix")
+	}
+	bug := f.Args[0]
+
+	base, err := f.BaseBranch()
+	if err != nil {
+		return errors.Annotate(err, "could not determine the base branch for the fix")
+	}
+	// no base chosen, user most likely hit Esc.
+	if base == "" {
+		return nil
+	}
+
+	slug, err := f.BranchSlug(bug)
+	if err != nil {
+		return errors.Annotate(err, "could not determine the slug for the fix branch")
+	}
+
+	fixBranch := util.BranchName{Type: util.FixType, Target: base, Reference: bug, Slug: slug}
+	created, err := util.NewBranch(f.NewGit, fixBranch)
+	if err != nil {
+		return errors.Annotate(err, "cannot create fix branch")
+	}
+	util.Reportf("now working in %q \n", created)
+	return nil
+}
+
+// BaseBranch returns the branch the fix should be based on, in order of
+// precedence: the one passed by the user, the configured default or the
+// one picked interactively.
+func (f *Command) BaseBranch() (string, error) {
+	if f.Base != "" {
+		return f.Base, nil
+	}
+	base, err := util.DefaultBase(f.NewGit, util.FixType)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if base != "" {
+		return base, nil
+	}
+	return util.PickBase(f.NewGit, f.UI)
+}
+
+// BranchSlug returns the slug for the fix branch name when the naming
+// scheme uses one, in order of precedence: the one passed by the user,
+// one made from the bug title in the tracker or the one typed by the user.
+func (f *Command) BranchSlug(bug string) (string, error) {
+	if f.Slug != "" {
+		return util.Slugify(f.Slug), nil
+	}
+	scheme, err := util.SchemeFor(f.NewGit, util.FixType)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if !scheme.UsesSlug() {
+		return "", nil
+	}
+	if t, err := tracker.FromConfig(f.NewGit); err == nil {
+		if titler, ok := t.(tracker.Titler); ok {
+			if title, err := titler.Title(bug); err == nil && util.Slugify(title) != "" {
+				return util.Slugify(title), nil
+			}
+		}
+	}
+	slug, err := f.UI.ValidatedInput("short description: ", validateSlug)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return util.Slugify(slug), nil
+}
+
+func validateSlug(slug string) error {
+	if util.Slugify(slug) == "" {
+		return errors.New("the description needs at least a letter or digit")
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package fix
+
+import (
+	"testing"
+
+	gtesting "github.com/perrito666/got/testing"
+)
+
+func TestHandleNoArgsFails(t *testing.T) {
+	c := Command{
+		Args:   []string{},
+		UI:     &gtesting.FakeUI{},
+		NewGit: gtesting.New,
+	}
+	if err := c.Handle(); err == nil {
+		t.Fatal("expected an error when no bug id is given")
+	}
+}
+
+func TestHandleCreatesBranch(t *testing.T) {
+	tests := map[string]struct {
+		base      string
+		slug      string
+		responses map[string]gtesting.Response
+		choices   [][]int
+		// created is the git call creating the branch, empty for none.
+		created string
+	}{
+		"given base": {base: "1.2", created: "checkout -b fix_1.2_12345 1.2"},
+		"default base": {
+			responses: map[string]gtesting.Response{"config --get got.fix.default": {Output: "1.3\n"}},
+			created:   "checkout -b fix_1.3_12345 1.3",
+		},
+		"picked base": {choices: [][]int{{1}}, created: "checkout -b fix_1.3_12345 1.3"},
+		// nothing is chosen, as if the user hit Esc.
+		"no base": {},
+		"slug": {
+			base:      "1.2",
+			slug:      "Login flow",
+			responses: map[string]gtesting.Response{"config --get got.fix.template": {Output: "bugfix/{target}/{id}-{slug}\n"}},
+			created:   "checkout -b bugfix/1.2/12345-login-flow 1.2",
+		},
+	}
+	for name, test := range tests {
+		script := &gtesting.ScriptedGit{}
+		script.AddBranches([]gtesting.Branch{{Name: "1.2", Current: true}, {Name: "1.3"}}, nil)
+		for key, response := range test.responses {
+			script.Responses[key] = response
+		}
+		c := Command{
+			Args:   []string{"12345"},
+			Base:   test.base,
+			Slug:   test.slug,
+			UI:     &gtesting.FakeUI{Choices: test.choices},
+			NewGit: script.New,
+		}
+		if err := c.Handle(); err != nil {
+			t.Logf("%s: unexpected error: %v", name, err)
+			t.Fail()
+			continue
+		}
+		created := script.CallsTo("checkout")
+		if test.created == "" && len(created) != 0 || test.created != "" && (len(created) != 1 || created[0] != test.created) {
+			t.Logf("%s: expected %q got %q", name, test.created, created)
+			t.Fail()
+		}
+	}
+}
+
+func TestBaseBranchPrefersGivenBase(t *testing.T) {
+	c := Command{
+		Base:   "1.2",
+		UI:     &gtesting.FakeUI{},
+		NewGit: gtesting.New,
+	}
+	base, err := c.BaseBranch()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if base != "1.2" {
+		t.Fatalf("expected %q got %q", "1.2", base)
+	}
+}
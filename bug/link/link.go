@@ -0,0 +1,64 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package link
+
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+	"github.com/perrito666/got/git"
+	"github.com/perrito666/got/tracker"
+	"github.com/perrito666/got/util"
+)
+
+// Command holds the configuration and methods required for the link
+// sub-command.
+type Command struct {
+	Args   []string
+	Title  bool
+	NewGit git.CompatibleConstructor
+}
+
+// Handle is the entry point for the Link sub-command.
+func (l *Command) Handle() error {
+	bug, err := l.Bug()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	t, err := tracker.FromConfig(l.NewGit)
+	if err != nil {
+		return errors.Annotate(err, "cannot determine the issue tracker")
+	}
+	fmt.Println(t.URL(bug))
+	if !l.Title {
+		return nil
+	}
+	titler, ok := t.(tracker.Titler)
+	if !ok {
+		return errors.NotSupportedf("bug titles for this tracker")
+	}
+	title, err := titler.Title(bug)
+	if err != nil {
+		return errors.Annotatef(err, "cannot fetch title for bug %q", bug)
+	}
+	fmt.Println(title)
+	return nil
+}
+
+// Bug returns the bug passed by the user or the one being fixed
+// in the current branch.
+func (l *Command) Bug() (string, error) {
+	if len(l.Args) > 0 {
+		return l.Args[0], nil
+	}
+	branch, err := git.NewRepo(l.NewGit).CurrentBranch()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	parsed, err := util.ResolveBranchName(l.NewGit, util.FixType, branch)
+	if err != nil {
+		return "", errors.Errorf("%q is not a fix branch", branch)
+	}
+	return parsed.Reference, nil
+}
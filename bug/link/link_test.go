@@ -0,0 +1,56 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package link
+
+import (
+	"testing"
+
+	gtesting "github.com/perrito666/got/testing"
+)
+
+func TestBug(t *testing.T) {
+	tests := map[string]struct {
+		args     []string
+		branch   string
+		template string
+		// bug is the expected bug, empty when an error is.
+		bug string
+	}{
+		"default template": {branch: "fix_1.2_12345", bug: "12345"},
+		"custom template":  {branch: "bugfix/1.2/12345-login-flow", template: "bugfix/{target}/{id}-{slug}", bug: "12345"},
+		"not a fix":        {branch: "master"},
+		"other template":   {branch: "fix_1.2_12345", template: "bugfix/{target}/{id}-{slug}"},
+		"explicit id":      {args: []string{"777"}, branch: "master", bug: "777"},
+	}
+	for name, test := range tests {
+		script := &gtesting.ScriptedGit{Responses: map[string]gtesting.Response{
+			"rev-parse --abbrev-ref HEAD": {Output: test.branch + "\n"},
+		}}
+		if test.template != "" {
+			script.Responses["config --get got.fix.template"] = gtesting.Response{Output: test.template + "\n"}
+		}
+		c := Command{Args: test.args, NewGit: script.New}
+		bug, err := c.Bug()
+		if test.bug == "" {
+			if err == nil {
+				t.Logf("%s: expected %q not to be taken as a fix branch, got bug %q", name, test.branch, bug)
+				t.Fail()
+			}
+			continue
+		}
+		if err != nil {
+			t.Logf("%s: unexpected error: %v", name, err)
+			t.Fail()
+			continue
+		}
+		if bug != test.bug {
+			t.Logf("%s: expected bug %q got %q", name, test.bug, bug)
+			t.Fail()
+		}
+		if len(test.args) > 0 && len(script.CallsTo("rev-parse")) != 0 {
+			t.Logf("%s: expected the current branch not to be read, got %q", name, script.Calls)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package port
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/perrito666/got/git"
+	"github.com/perrito666/got/interfaces"
+	"github.com/perrito666/got/util"
+)
+
+const (
+	// sourceKey holds the fix branch being ported while a port is in progress.
+	sourceKey = "got.port.source"
+	// branchKey holds the branch being ported to while a port is in progress.
+	branchKey = "got.port.branch"
+)
+
+// Command holds the configuration and methods required for the port
+// sub-command.
+type Command struct {
+	Args     []string
+	Target   string
+	Merge    bool
+	Abort    bool
+	Continue bool
+	UI       interfaces.UI
+	NewGit   git.CompatibleConstructor
+}
+
+// Handle is the entry point for the Port sub-command.
+func (p *Command) Handle() error {
+	if p.Abort {
+		return p.AbortPort()
+	}
+	if p.Continue {
+		return p.ContinuePort()
+	}
+
+	repo := git.NewRepo(p.NewGit)
+	inProgress, err := repo.ConfigGet(sourceKey)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if inProgress != "" {
+		return errors.Errorf("a port of %q is in progress, use --continue or --abort", inProgress)
+	}
+
+	status, err := repo.Status()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !status.Clean() {
+		return errors.New("cannot port with uncommitted changes, commit or stash them first")
+	}
+
+	source, err := repo.CurrentBranch()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	parsed, err := util.ResolveBranchName(p.NewGit, util.FixType, source)
+	if err != nil || parsed.Target == "" {
+		return errors.Errorf("%q is not a fix branch", source)
+	}
+	from, bug := parsed.Target, parsed.Reference
+
+	target := p.Target
+	if target == "" {
+		if target, err = util.PickBase(p.NewGit, p.UI, from); err != nil {
+			return errors.Annotate(err, "could not select a branch to port to")
+		}
+		// no target chosen, user most likely hit Esc.
+		if target == "" {
+			return nil
+		}
+	}
+	if target == from {
+		return errors.Errorf("%q is already based on %q", source, target)
+	}
+
+	port := util.BranchName{Type: util.FixType, Target: target, Reference: bug, Slug: parsed.Slug}
+	if p.Merge {
+		return p.portMerge(source, from, port)
+	}
+
+	base, err := repo.MergeBase(from, source)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	commits, err := repo.Log("--reverse", "--no-merges", base+".."+source)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(commits) == 0 {
+		return errors.Errorf("%q has no commits to port", source)
+	}
+	hashes := make([]string, len(commits))
+	for i, commit := range commits {
+		hashes[i] = commit.Hash
+	}
+
+	created, err := p.startPort(source, port)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return p.cherryPick(source, created, append([]string{"-x"}, hashes...))
+}
+
+// portMerge updates the branch the fix was made for and ports the commit
+// that landed the fix there, as chosen by the user, into the port branch.
+// Unless the port branch is created the fix branch is checked out again.
+func (p *Command) portMerge(source, from string, port util.BranchName) (err error) {
+	created := ""
+	defer func() {
+		if created != "" {
+			return
+		}
+		if checkoutErr := p.NewGit(git.SCMDCheckout, nil).Checkout(source); checkoutErr != nil && err == nil {
+			err = errors.Trace(checkoutErr)
+		}
+	}()
+	if err := p.NewGit(git.SCMDCheckout, nil).Checkout(from); err != nil {
+		return errors.Trace(err)
+	}
+	if err := p.NewGit("pull", []string{"-r"}).Run(); err != nil {
+		return errors.Annotatef(err, "cannot update %q", from)
+	}
+
+	repo := git.NewRepo(p.NewGit)
+	base, err := repo.MergeBase(from, source)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// only what landed since the fix branched off can be the fix.
+	commits, err := repo.Log("--first-parent", base+".."+from)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(commits) == 0 {
+		return errors.Errorf("nothing landed in %q since %q branched off", from, source)
+	}
+	commit, err := util.PickCommit(relevantFirst(commits, source, port.Reference), p.UI)
+	if err != nil {
+		return errors.Annotate(err, "could not select the commit that merged the fix")
+	}
+	// no commit chosen, user most likely hit Esc.
+	if commit == nil {
+		return nil
+	}
+
+	if created, err = p.startPort(source, port); err != nil {
+		return errors.Trace(err)
+	}
+	args := []string{"-x"}
+	if commit.IsMerge() {
+		// port what the merge brought into its first parent.
+		args = append(args, "-m", "1")
+	}
+	return p.cherryPick(source, created, append(args, commit.Hash))
+}
+
+// relevantFirst sorts the commits that mention the fix branch or the bug,
+// such as github's "Merge pull request #N from user/fix_1.2_1234", before
+// the rest, preserving the original order otherwise.
+func relevantFirst(commits []git.Commit, branch, bug string) []git.Commit {
+	mentionsBug := regexp.MustCompile(`(^|[^[:alnum:]])` + regexp.QuoteMeta(bug) + `($|[^[:alnum:]])`)
+	relevant := []git.Commit{}
+	rest := []git.Commit{}
+	for _, commit := range commits {
+		if strings.Contains(commit.Subject, branch) || mentionsBug.MatchString(commit.Subject) {
+			relevant = append(relevant, commit)
+			continue
+		}
+		rest = append(rest, commit)
+	}
+	return append(relevant, rest...)
+}
+
+// startPort records the port of source and then creates the port
+// branch, so that once the branch exists the port can always be undone.
+func (p *Command) startPort(source string, port util.BranchName) (string, error) {
+	scheme, err := util.SchemeFor(p.NewGit, port.Type)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	repo := git.NewRepo(p.NewGit)
+	if err := repo.ConfigSet(sourceKey, source); err != nil {
+		return "", errors.Trace(err)
+	}
+	if err := repo.ConfigSet(branchKey, port.WithScheme(scheme).Format()); err != nil {
+		// a half recorded port would block the next one.
+		_ = p.clearState()
+		return "", errors.Trace(err)
+	}
+	created, err := util.NewBranch(p.NewGit, port)
+	if err != nil {
+		// there is nothing to undo.
+		_ = p.clearState()
+		return "", errors.Annotate(err, "cannot create port branch")
+	}
+	return created, nil
+}
+
+// cherryPick runs cherry-pick with args on top of the port branch, the
+// port is recorded so it can be resumed or undone if it stops on a
+// conflict.
+func (p *Command) cherryPick(source, created string, args []string) error {
+	if err := p.NewGit("cherry-pick", args).Run(); err != nil {
+		return p.portError(err, source, created)
+	}
+	return p.done(source, created)
+}
+
+// ContinuePort resumes a port that stopped on a conflict, once the user
+// has resolved it.
+func (p *Command) ContinuePort() error {
+	source, created, err := p.state()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := p.NewGit("cherry-pick", []string{"--continue"}).Run(); err != nil {
+		return p.portError(err, source, created)
+	}
+	return p.done(source, created)
+}
+
+// AbortPort undoes a port that stopped on a conflict, returning to the
+// fix branch and removing the branch that was being ported to.
+func (p *Command) AbortPort() error {
+	source, created, err := p.state()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// the cherry-pick might have been aborted by hand already.
+	_ = p.NewGit("cherry-pick", []string{"--abort"}).Run()
+	if err := p.NewGit(git.SCMDCheckout, nil).Checkout(source); err != nil {
+		return errors.Trace(err)
+	}
+	if err := util.DeleteBranch(p.NewGit, created); err != nil {
+		return errors.Trace(err)
+	}
+	if err := p.clearState(); err != nil {
+		return errors.Trace(err)
+	}
+	util.Reportf("port of %q aborted, now working in %q \n", source, source)
+	return nil
+}
+
+func (p *Command) done(source, created string) error {
+	if err := p.clearState(); err != nil {
+		return errors.Trace(err)
+	}
+	util.Reportf("ported %q, now working in %q \n", source, created)
+	return nil
+}
+
+func (p *Command) state() (string, string, error) {
+	repo := git.NewRepo(p.NewGit)
+	source, err := repo.ConfigGet(sourceKey)
+	if err != nil {
+		return "", "", errors.Trace(err)
+	}
+	created, err := repo.ConfigGet(branchKey)
+	if err != nil {
+		return "", "", errors.Trace(err)
+	}
+	if source == "" || created == "" {
+		return "", "", errors.New("there is no port in progress")
+	}
+	return source, created, nil
+}
+
+func (p *Command) clearState() error {
+	repo := git.NewRepo(p.NewGit)
+	if err := repo.ConfigUnset(sourceKey); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(repo.ConfigUnset(branchKey))
+}
+
+// portError tells the user how to proceed with a port that stopped, git
+// leaves CHERRY_PICK_HEAD behind when it stops on conflicts.
+func (p *Command) portError(err error, source, created string) error {
+	conflicted, verifyErr := git.NewRepo(p.NewGit).RefExists("CHERRY_PICK_HEAD")
+	if verifyErr == nil && conflicted {
+		return errors.Errorf("porting %q to %q stopped, resolve the conflicts and run "+
+			"\"got bug port --continue\" or undo it with \"got bug port --abort\"", source, created)
+	}
+	return errors.Annotatef(err, "porting %q to %q failed, undo it with \"got bug port --abort\"", source, created)
+}
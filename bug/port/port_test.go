@@ -0,0 +1,156 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package port
+
+import (
+	"testing"
+
+	"github.com/perrito666/got/git"
+	gtesting "github.com/perrito666/got/testing"
+)
+
+func TestHandleRefusesNonFixBranch(t *testing.T) {
+	c := Command{
+		Args:   []string{},
+		Target: "1.2",
+		UI:     &gtesting.FakeUI{},
+		NewGit: gtesting.New,
+	}
+	if err := c.Handle(); err == nil {
+		t.Fatal("expected an error when not on a fix branch")
+	}
+}
+
+func TestContinueWithoutPortFails(t *testing.T) {
+	c := Command{
+		Continue: true,
+		UI:       &gtesting.FakeUI{},
+		NewGit:   gtesting.New,
+	}
+	if err := c.Handle(); err == nil {
+		t.Fatal("expected an error when there is no port in progress")
+	}
+}
+
+func TestAbortWithoutPortFails(t *testing.T) {
+	c := Command{
+		Abort:  true,
+		UI:     &gtesting.FakeUI{},
+		NewGit: gtesting.New,
+	}
+	if err := c.Handle(); err == nil {
+		t.Fatal("expected an error when there is no port in progress")
+	}
+}
+
+func TestRelevantFirst(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "a", Subject: "Unrelated change"},
+		{Hash: "b", Subject: "Merge pull request #42 from someone/fix_1.2_1234"},
+		{Hash: "c", Subject: "Bump version to 12345"},
+		{Hash: "d", Subject: "Fix crash on startup (LP: 1234)"},
+	}
+	sorted := relevantFirst(commits, "fix_1.2_1234", "1234")
+	expected := []string{"b", "d", "a", "c"}
+	for i, hash := range expected {
+		if sorted[i].Hash != hash {
+			t.Logf("expected %q at %d got %q", hash, i, sorted[i].Hash)
+			t.Fail()
+		}
+	}
+}
+
+// portScript is a repository on fix_1.2_1 with one commit to port.
+func portScript() *gtesting.ScriptedGit {
+	script := &gtesting.ScriptedGit{Responses: map[string]gtesting.Response{
+		"rev-parse --abbrev-ref HEAD": {Output: "fix_1.2_1\n"},
+		"merge-base 1.2 fix_1.2_1":    {Output: "a\n"},
+		"log --reverse a..fix_1.2_1":  {Output: "c\x00a\x00Fix the crash\n"},
+	}}
+	script.AddBranches([]gtesting.Branch{{Name: "1.2"}, {Name: "1.3"}, {Name: "fix_1.2_1", Current: true}}, nil)
+	return script
+}
+
+func indexOf(calls []string, call string) int {
+	for i, c := range calls {
+		if c == call {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestHandleRecordsPortBeforeBranch(t *testing.T) {
+	script := portScript()
+	c := Command{
+		Target: "1.3",
+		UI:     &gtesting.FakeUI{},
+		NewGit: script.New,
+	}
+	if err := c.Handle(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	recorded := indexOf(script.Calls, "config got.port.branch fix_1.3_1")
+	created := indexOf(script.Calls, "checkout -b fix_1.3_1 1.3")
+	if recorded < 0 || created < 0 || recorded > created {
+		t.Fatalf("expected the port to be recorded before the branch is created, got %q", script.Calls)
+	}
+}
+
+func TestHandleForgetsPortWithoutBranch(t *testing.T) {
+	script := portScript()
+	script.Responses["checkout -b fix_1.3_1"] = gtesting.Response{ExitCode: 128, Stderr: "fatal: a branch named 'fix_1.3_1' already exists"}
+	c := Command{
+		Target: "1.3",
+		UI:     &gtesting.FakeUI{},
+		NewGit: script.New,
+	}
+	if err := c.Handle(); err == nil {
+		t.Fatal("expected an error when the port branch cannot be created")
+	}
+	for _, call := range []string{"config --unset got.port.source", "config --unset got.port.branch"} {
+		if indexOf(script.Calls, call) < 0 {
+			t.Logf("expected %q got %q", call, script.Calls)
+			t.Fail()
+		}
+	}
+	if picks := script.CallsTo("cherry-pick"); len(picks) != 0 {
+		t.Fatalf("expected nothing to be cherry picked, got %q", picks)
+	}
+}
+
+func TestPortMergeReturnsToFix(t *testing.T) {
+	tests := map[string]struct {
+		responses map[string]gtesting.Response
+		choices   [][]int
+		// last is the branch left checked out.
+		last string
+	}{
+		// nothing is chosen, as if the user hit Esc.
+		"cancelled":     {nil, nil, "checkout fix_1.2_1"},
+		"pull fails":    {map[string]gtesting.Response{"pull": {ExitCode: 1}}, nil, "checkout fix_1.2_1"},
+		"nothing new":   {map[string]gtesting.Response{"log --first-parent a..1.2": {}}, nil, "checkout fix_1.2_1"},
+		"branch exists": {map[string]gtesting.Response{"checkout -b fix_1.3_1": {ExitCode: 128}}, [][]int{{0}}, "checkout fix_1.2_1"},
+		"ported":        {nil, [][]int{{0}}, "checkout -b fix_1.3_1 1.3"},
+	}
+	for name, test := range tests {
+		script := portScript()
+		script.Responses["log --first-parent a..1.2"] = gtesting.Response{Output: "d\x00a c\x00Merge fix_1.2_1\n"}
+		for key, response := range test.responses {
+			script.Responses[key] = response
+		}
+		c := Command{
+			Target: "1.3",
+			Merge:  true,
+			UI:     &gtesting.FakeUI{Choices: test.choices},
+			NewGit: script.New,
+		}
+		_ = c.Handle()
+		checkouts := script.CallsTo("checkout")
+		if len(checkouts) < 2 || checkouts[0] != "checkout 1.2" || checkouts[len(checkouts)-1] != test.last {
+			t.Logf("%s: expected to end with %q, got %q", name, test.last, checkouts)
+			t.Fail()
+		}
+	}
+}
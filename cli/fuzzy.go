@@ -0,0 +1,111 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package cli
+
+import (
+	"sort"
+	"unicode"
+
+	"github.com/perrito666/got/interfaces"
+)
+
+const (
+	// consecutiveBonus is awarded for each match right after another one.
+	consecutiveBonus = 5
+	// boundaryBonus is awarded for each match at the start of a word.
+	boundaryBonus = 3
+	// startWeight makes the start of a match count less than any bonus.
+	startWeight = 100
+)
+
+// match is an item that matched the menu filter.
+type match struct {
+	// index is the position of the item in the original list.
+	index int
+	// positions holds the matched runes of the item.
+	positions []int
+	score     int
+}
+
+// fuzzyMatch reports whether all the runes of pattern appear in text in
+// order, ignoring case. It returns the positions of the best match found
+// and its score, which favours consecutive runes and word starts.
+func fuzzyMatch(pattern, text []rune) ([]int, int, bool) {
+	if len(pattern) == 0 {
+		return nil, 0, true
+	}
+	var best []int
+	bestScore := 0
+	first := unicode.ToLower(pattern[0])
+	for start, r := range text {
+		if unicode.ToLower(r) != first {
+			continue
+		}
+		positions, ok := matchFrom(pattern, text, start)
+		if !ok {
+			// no later start can match either.
+			break
+		}
+		if score := scoreMatch(text, positions); best == nil || score > bestScore {
+			best, bestScore = positions, score
+		}
+	}
+	return best, bestScore, best != nil
+}
+
+// matchFrom greedily matches pattern in text starting at start, which
+// must match the first rune of pattern.
+func matchFrom(pattern, text []rune, start int) ([]int, bool) {
+	positions := make([]int, 0, len(pattern))
+	p := 0
+	for i := start; i < len(text) && p < len(pattern); i++ {
+		if unicode.ToLower(text[i]) == unicode.ToLower(pattern[p]) {
+			positions = append(positions, i)
+			p++
+		}
+	}
+	return positions, p == len(pattern)
+}
+
+func scoreMatch(text []rune, positions []int) int {
+	score := 0
+	for i, pos := range positions {
+		score++
+		if i > 0 && positions[i-1] == pos-1 {
+			score += consecutiveBonus
+		}
+		if pos == 0 || isSeparator(text[pos-1]) {
+			score += boundaryBonus
+		}
+	}
+	// the earlier the match starts the better, as a tie breaker.
+	start := positions[0]
+	if start >= startWeight {
+		start = startWeight - 1
+	}
+	return score*startWeight - start
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// filterItems returns the items whose name matches query, best matches
+// first and in their original order otherwise.
+func filterItems(items []interfaces.Item, query []rune) []match {
+	matches := []match{}
+	for i, item := range items {
+		positions, score, ok := fuzzyMatch(query, []rune(item.Name))
+		if !ok {
+			continue
+		}
+		matches = append(matches, match{index: i, positions: positions, score: score})
+	}
+	if len(query) > 0 {
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].score > matches[j].score
+		})
+	}
+	return matches
+}
@@ -0,0 +1,115 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/perrito666/got/interfaces"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		text      string
+		positions []int
+		ok        bool
+	}{
+		{"", "fix_1.2_123", nil, true},
+		{"fix", "fix_1.2_123", []int{0, 1, 2}, true},
+		{"F12", "fix_1.2_123", []int{0, 4, 6}, true},
+		{"123", "fix_1.2_123", []int{8, 9, 10}, true},
+		{"ñu", "año_ñu", []int{4, 5}, true},
+		{"321", "fix_1.2_123", nil, false},
+		{"fixes", "fix_1.2_123", nil, false},
+	}
+	for _, test := range tests {
+		positions, _, ok := fuzzyMatch([]rune(test.pattern), []rune(test.text))
+		if ok != test.ok {
+			t.Logf("expected match %v for %q in %q", test.ok, test.pattern, test.text)
+			t.Fail()
+			continue
+		}
+		if !reflect.DeepEqual(positions, test.positions) {
+			t.Logf("expected positions %v for %q in %q got %v", test.positions, test.pattern, test.text, positions)
+			t.Fail()
+		}
+	}
+}
+
+func TestFilterItemsSortsByScore(t *testing.T) {
+	items := interfaces.NamedItems([]string{"fix_master_4711", "fix_1.2_1234", "fix_1.3_1234", "feature_login"})
+	matches := filterItems(items, []rune("1234"))
+	indices := []int{}
+	for _, m := range matches {
+		indices = append(indices, m.index)
+	}
+	if !reflect.DeepEqual(indices, []int{1, 2}) {
+		t.Fatalf("expected [1 2] got %v", indices)
+	}
+
+	matches = filterItems(items, []rune("log"))
+	if len(matches) != 1 || matches[0].index != 3 {
+		t.Fatalf("expected only feature_login, got %v", matches)
+	}
+}
+
+func TestMenuChosenRefersToOriginalItems(t *testing.T) {
+	items := interfaces.NamedItems([]string{"master", "1.2", "1.3", "fix_1.2_1234"})
+	m := newMenu(items, false, nil)
+	for _, r := range "1.3" {
+		m.typeRune(r)
+	}
+	m.toggle()
+	m.backspace()
+	m.backspace()
+	m.backspace()
+	m.typeRune('f')
+	m.toggle()
+	if chosen := m.chosen(); !reflect.DeepEqual(chosen, []int{2, 3}) {
+		t.Fatalf("expected [2 3] got %v", chosen)
+	}
+}
+
+func TestMenuOneChoosesCursor(t *testing.T) {
+	m := newMenu(interfaces.NamedItems([]string{"master", "1.2", "1.3"}), true, nil)
+	m.move(5)
+	if chosen := m.chosen(); !reflect.DeepEqual(chosen, []int{2}) {
+		t.Fatalf("expected [2] got %v", chosen)
+	}
+	m.typeRune('x')
+	if chosen := m.chosen(); chosen != nil {
+		t.Fatalf("expected nothing chosen without matches, got %v", chosen)
+	}
+}
+
+func TestMenuScroll(t *testing.T) {
+	m := newMenu(interfaces.NamedItems([]string{"a", "b", "c", "d", "e"}), true, nil)
+	m.move(4)
+	m.scroll(2)
+	if m.offset != 3 {
+		t.Fatalf("expected offset 3 got %d", m.offset)
+	}
+	m.move(-4)
+	m.scroll(2)
+	if m.offset != 0 {
+		t.Fatalf("expected offset 0 got %d", m.offset)
+	}
+}
+
+func TestMenuPreselectsCurrent(t *testing.T) {
+	m := newMenu(interfaces.NamedItems([]string{"master", "1.2", "1.3", "1.4"}), false, []int{2, 0, 9})
+	if m.cursor != 2 {
+		t.Fatalf("expected the cursor on 2 got %d", m.cursor)
+	}
+	if chosen := m.chosen(); !reflect.DeepEqual(chosen, []int{0, 2}) {
+		t.Fatalf("expected [0 2] got %v", chosen)
+	}
+
+	m = newMenu(interfaces.NamedItems([]string{"master", "1.2", "1.3"}), true, []int{1})
+	if chosen := m.chosen(); !reflect.DeepEqual(chosen, []int{1}) {
+		t.Fatalf("expected [1] got %v", chosen)
+	}
+}
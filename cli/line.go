@@ -0,0 +1,102 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/perrito666/got/interfaces"
+)
+
+// lineUI implements the prompts of interfaces.UI by reading plain lines,
+// it is used when there is no terminal for ncurses to drive.
+type lineUI struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func newLineUI(in io.Reader, out io.Writer) *lineUI {
+	return &lineUI{
+		in:  bufio.NewReader(in),
+		out: out,
+	}
+}
+
+// stdLine is shared so that input buffered from stdin is not lost
+// between prompts.
+var stdLine = newLineUI(os.Stdin, os.Stdout)
+
+// isTerminal returns true if f is a character device, such as a tty.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// readLine prompts and returns the next line without its line break,
+// io.EOF is returned only when there is nothing left to read.
+func (l *lineUI) readLine(prompt string) (string, error) {
+	fmt.Fprint(l.out, prompt)
+	line, err := l.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// Input implements interfaces.UI, the end of the input is
+// taken as an empty answer.
+func (l *lineUI) Input(prompt string) (string, error) {
+	text, err := l.readLine(prompt)
+	if err == io.EOF {
+		return "", nil
+	}
+	return text, errors.Trace(err)
+}
+
+// Confirm implements interfaces.UI, anything but yes is a no.
+func (l *lineUI) Confirm(prompt string) (bool, error) {
+	answer, err := l.readLine(prompt + " [y/N] ")
+	if err == io.EOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return isYes(answer), nil
+}
+
+// ValidatedInput implements interfaces.UI, it prompts again until
+// the input is valid or there is no more input.
+func (l *lineUI) ValidatedInput(prompt string, validate interfaces.Validator) (string, error) {
+	for {
+		text, err := l.readLine(prompt)
+		if err == io.EOF {
+			return "", errors.New("input ended without a valid answer")
+		}
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		if err := validate(text); err != nil {
+			fmt.Fprintf(l.out, "%v\n", err)
+			continue
+		}
+		return text, nil
+	}
+}
+
+func isYes(answer string) bool {
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
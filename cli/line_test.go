@@ -0,0 +1,71 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/juju/errors"
+)
+
+func TestLineInput(t *testing.T) {
+	out := &bytes.Buffer{}
+	l := newLineUI(strings.NewReader("  my feature \nsecond\n"), out)
+	text, err := l.Input("name: ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text != "my feature" {
+		t.Fatalf("expected %q got %q", "my feature", text)
+	}
+	if out.String() != "name: " {
+		t.Fatalf("expected the prompt to be printed, got %q", out.String())
+	}
+	if text, _ = l.Input("again: "); text != "second" {
+		t.Fatalf("expected %q got %q", "second", text)
+	}
+	if text, err = l.Input("done: "); text != "" || err != nil {
+		t.Fatalf("expected an empty answer at the end of input, got %q, %v", text, err)
+	}
+}
+
+func TestLineConfirm(t *testing.T) {
+	l := newLineUI(strings.NewReader("y\nYes\nno\n\n"), &bytes.Buffer{})
+	for i, expected := range []bool{true, true, false, false, false} {
+		answer, err := l.Confirm("sure?")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if answer != expected {
+			t.Logf("answer %d: expected %v got %v", i, expected, answer)
+			t.Fail()
+		}
+	}
+}
+
+func TestLineValidatedInput(t *testing.T) {
+	out := &bytes.Buffer{}
+	l := newLineUI(strings.NewReader("\nabc\n123\n"), out)
+	numeric := func(s string) error {
+		if s == "" || strings.Trim(s, "0123456789") != "" {
+			return errors.Errorf("%q is not a number", s)
+		}
+		return nil
+	}
+	text, err := l.ValidatedInput("bug: ", numeric)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text != "123" {
+		t.Fatalf("expected %q got %q", "123", text)
+	}
+	if !strings.Contains(out.String(), `"abc" is not a number`) {
+		t.Fatalf("expected the validation error to be shown, got %q", out.String())
+	}
+	if _, err := l.ValidatedInput("bug: ", numeric); err == nil {
+		t.Fatal("expected an error at the end of input")
+	}
+}
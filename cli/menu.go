@@ -0,0 +1,127 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package cli
+
+import (
+	"sort"
+
+	"github.com/perrito666/got/interfaces"
+)
+
+// menu holds the state of a choice menu filtered as the user types,
+// the chosen indices always refer to the original items.
+type menu struct {
+	items    []interfaces.Item
+	one      bool
+	query    []rune
+	matches  []match
+	cursor   int
+	offset   int
+	selected map[int]bool
+}
+
+// newMenu returns a menu with the current items selected, when only one
+// can be chosen the first of them is put under the cursor instead.
+func newMenu(items []interfaces.Item, one bool, current []int) *menu {
+	m := &menu{
+		items:    items,
+		one:      one,
+		selected: map[int]bool{},
+	}
+	m.filter()
+	for i, index := range current {
+		if index < 0 || index >= len(items) {
+			continue
+		}
+		if i == 0 {
+			m.moveTo(index)
+		}
+		if !one {
+			m.selected[index] = true
+		}
+	}
+	return m
+}
+
+func (m *menu) filter() {
+	m.matches = filterItems(m.items, m.query)
+	m.cursor = 0
+	m.offset = 0
+}
+
+// typeRune adds r to the filter.
+func (m *menu) typeRune(r rune) {
+	m.query = append(m.query, r)
+	m.filter()
+}
+
+// backspace removes the last rune of the filter.
+func (m *menu) backspace() {
+	if len(m.query) == 0 {
+		return
+	}
+	m.query = m.query[:len(m.query)-1]
+	m.filter()
+}
+
+// move moves the cursor delta positions within the visible matches.
+func (m *menu) move(delta int) {
+	m.moveTo(m.cursor + delta)
+}
+
+func (m *menu) moveTo(position int) {
+	if position >= len(m.matches) {
+		position = len(m.matches) - 1
+	}
+	if position < 0 {
+		position = 0
+	}
+	m.cursor = position
+}
+
+// scroll makes sure the cursor is within the height rows shown.
+func (m *menu) scroll(height int) {
+	if height < 1 {
+		height = 1
+	}
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+height {
+		m.offset = m.cursor - height + 1
+	}
+}
+
+// toggle flips the selection of the item under the cursor.
+func (m *menu) toggle() {
+	if current, ok := m.current(); ok {
+		m.selected[current.index] = !m.selected[current.index]
+	}
+}
+
+func (m *menu) current() (match, bool) {
+	if len(m.matches) == 0 {
+		return match{}, false
+	}
+	return m.matches[m.cursor], true
+}
+
+// chosen returns the item under the cursor if only one can be chosen
+// or the selected ones otherwise, as indices of the original items.
+func (m *menu) chosen() []int {
+	var list []int
+	if m.one {
+		if current, ok := m.current(); ok {
+			list = append(list, current.index)
+		}
+		return list
+	}
+	for index, selected := range m.selected {
+		if selected {
+			list = append(list, index)
+		}
+	}
+	sort.Ints(list)
+	return list
+}
@@ -0,0 +1,154 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/perrito666/got/interfaces"
+)
+
+// Plain forces New to return a PlainUI even if there is a terminal.
+var Plain bool
+
+// New returns the ncurses UI if got is running in a capable terminal
+// and a PlainUI otherwise.
+func New() interfaces.UI {
+	if Plain || !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return NewPlain(os.Stdin, os.Stdout)
+	}
+	if term := os.Getenv("TERM"); term == "" || term == "dumb" {
+		return NewPlain(os.Stdin, os.Stdout)
+	}
+	return &UI{}
+}
+
+// PlainUI is a line based UI for pipes, CI and dumb terminals, choices
+// are printed as a numbered list and picked by number.
+type PlainUI struct {
+	*lineUI
+}
+
+// NewPlain returns a PlainUI that reads answers from in and prints to out.
+func NewPlain(in io.Reader, out io.Writer) *PlainUI {
+	if in == os.Stdin {
+		return &PlainUI{stdLine}
+	}
+	return &PlainUI{newLineUI(in, out)}
+}
+
+// ChoiceMenu presents the user with a numbered list of choices, one
+// is picked by its number and many by a list of numbers and ranges
+// such as 1,3-5. The items in current are marked with an asterisk and
+// an empty answer picks them, without any it cancels as 0 always does.
+func (p *PlainUI) ChoiceMenu(items []interfaces.Item, one bool, current []int) ([]int, error) {
+	marked := make(map[int]bool, len(current))
+	for _, index := range current {
+		marked[index] = true
+	}
+	for i, item := range items {
+		mark := " "
+		if marked[i] {
+			mark = "*"
+		}
+		if item.Description == "" {
+			fmt.Fprintf(p.out, "%s%3d) %s\n", mark, i+1, item.Name)
+			continue
+		}
+		fmt.Fprintf(p.out, "%s%3d) %s%s%s\n", mark, i+1, item.Name, descriptionSeparator, item.Description)
+	}
+	if one && len(current) > 1 {
+		current = current[:1]
+	}
+	prompt := choicePrompt(len(items), one, len(current) > 0)
+	for {
+		answer, err := p.Input(prompt)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		switch answer {
+		case "":
+			if len(current) == 0 {
+				return nil, nil
+			}
+			return current, nil
+		case "0":
+			return nil, nil
+		}
+		selection, err := parseSelection(answer, len(items), one)
+		if err != nil {
+			fmt.Fprintf(p.out, "%v\n", err)
+			continue
+		}
+		return selection, nil
+	}
+}
+
+// choicePrompt returns the prompt for a choice among count items, it
+// tells what an empty answer does.
+func choicePrompt(count int, one, marked bool) string {
+	choices := "1"
+	if count > 1 {
+		choices = fmt.Sprintf("1-%d", count)
+	}
+	prompt := fmt.Sprintf("select one [%s]", choices)
+	if !one {
+		example := choices
+		switch {
+		case count > 3:
+			example = fmt.Sprintf("1,3-%d", count)
+		case count > 1:
+			example = fmt.Sprintf("1,%d", count)
+		}
+		prompt = fmt.Sprintf("select any [ie: %s]", example)
+	}
+	if !marked {
+		return prompt + ", empty to cancel: "
+	}
+	if one {
+		return prompt + ", empty for the marked one, 0 to cancel: "
+	}
+	return prompt + ", empty for the marked ones, 0 to cancel: "
+}
+
+// parseSelection turns a list of 1 based numbers and ranges, such as
+// 1,3-5, into sorted 0 based indices of a list of count items.
+func parseSelection(answer string, count int, one bool) ([]int, error) {
+	chosen := map[int]bool{}
+	for _, part := range strings.Split(answer, ",") {
+		part = strings.TrimSpace(part)
+		bounds := strings.SplitN(part, "-", 2)
+		from, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		if err != nil {
+			return nil, errors.NotValidf("selection %q", part)
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, err = strconv.Atoi(strings.TrimSpace(bounds[1])); err != nil {
+				return nil, errors.NotValidf("selection %q", part)
+			}
+		}
+		if from < 1 || to > count || from > to {
+			return nil, errors.NotValidf("selection %q out of 1-%d", part, count)
+		}
+		for i := from; i <= to; i++ {
+			chosen[i-1] = true
+		}
+	}
+	if one && len(chosen) != 1 {
+		return nil, errors.NotValidf("selection %q, choose only one", answer)
+	}
+	selection := make([]int, 0, len(chosen))
+	for i := range chosen {
+		selection = append(selection, i)
+	}
+	sort.Ints(selection)
+	return selection, nil
+}
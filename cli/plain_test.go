@@ -0,0 +1,170 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package cli
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/perrito666/got/interfaces"
+)
+
+func TestParseSelection(t *testing.T) {
+	tests := []struct {
+		answer   string
+		one      bool
+		expected []int
+	}{
+		{"1", true, []int{0}},
+		{" 3 ", true, []int{2}},
+		{"1,3", false, []int{0, 2}},
+		{"2-4", false, []int{1, 2, 3}},
+		{"5, 1-2,2", false, []int{0, 1, 4}},
+		{"3-3", true, []int{2}},
+	}
+	for _, test := range tests {
+		selection, err := parseSelection(test.answer, 5, test.one)
+		if err != nil {
+			t.Logf("unexpected error for %q: %v", test.answer, err)
+			t.Fail()
+			continue
+		}
+		if !reflect.DeepEqual(selection, test.expected) {
+			t.Logf("expected %v for %q got %v", test.expected, test.answer, selection)
+			t.Fail()
+		}
+	}
+}
+
+func TestParseSelectionInvalid(t *testing.T) {
+	tests := []struct {
+		answer string
+		one    bool
+	}{
+		{"0", true},
+		{"6", true},
+		{"a", true},
+		{"1,2", true},
+		{"1-2", true},
+		{"4-2", false},
+		{"1-x", false},
+		{"1,", false},
+	}
+	for _, test := range tests {
+		if _, err := parseSelection(test.answer, 5, test.one); err == nil {
+			t.Logf("expected an error for %q", test.answer)
+			t.Fail()
+		}
+	}
+}
+
+func TestPlainChoiceMenu(t *testing.T) {
+	out := &bytes.Buffer{}
+	p := NewPlain(strings.NewReader("7\n2\n"), out)
+	selection, err := p.ChoiceMenu(interfaces.NamedItems([]string{"master", "1.2", "1.3"}), true, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(selection, []int{1}) {
+		t.Fatalf("expected [1] got %v", selection)
+	}
+	if !strings.Contains(out.String(), "   2) 1.2\n") {
+		t.Fatalf("expected a numbered list, got %q", out.String())
+	}
+	if !strings.Contains(out.String(), "out of 1-3") {
+		t.Fatalf("expected the invalid choice to be reported, got %q", out.String())
+	}
+}
+
+func TestPlainChoiceMenuCancel(t *testing.T) {
+	p := NewPlain(strings.NewReader("\n"), &bytes.Buffer{})
+	selection, err := p.ChoiceMenu(interfaces.NamedItems([]string{"master", "1.2"}), false, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if selection != nil {
+		t.Fatalf("expected no selection got %v", selection)
+	}
+}
+
+func TestPlainChoiceMenuMarksCurrent(t *testing.T) {
+	out := &bytes.Buffer{}
+	p := NewPlain(strings.NewReader("1\n"), out)
+	if _, err := p.ChoiceMenu(interfaces.NamedItems([]string{"master", "1.2"}), false, []int{1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "*  2) 1.2\n") {
+		t.Fatalf("expected the current item to be marked, got %q", out.String())
+	}
+}
+
+func TestPlainChoiceMenuEmptyPicksCurrent(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		one      bool
+		current  []int
+		expected []int
+	}{
+		"marked ones":  {"\n", false, []int{0, 2}, []int{0, 2}},
+		"marked one":   {"\n", true, []int{1}, []int{1}},
+		"end of input": {"", false, []int{0, 1, 2}, []int{0, 1, 2}},
+		"zero cancels": {"0\n", false, []int{0, 2}, nil},
+		"none marked":  {"\n", false, nil, nil},
+		"other choice": {"2\n", true, []int{0}, []int{1}},
+	}
+	for name, test := range tests {
+		p := NewPlain(strings.NewReader(test.input), &bytes.Buffer{})
+		selection, err := p.ChoiceMenu(interfaces.NamedItems([]string{"master", "1.2", "1.3"}), test.one, test.current)
+		if err != nil {
+			t.Logf("%s: unexpected error: %v", name, err)
+			t.Fail()
+			continue
+		}
+		if !reflect.DeepEqual(selection, test.expected) {
+			t.Logf("%s: expected %v got %v", name, test.expected, selection)
+			t.Fail()
+		}
+	}
+}
+
+func TestChoicePrompt(t *testing.T) {
+	tests := []struct {
+		count    int
+		one      bool
+		marked   bool
+		expected string
+	}{
+		{1, true, false, "select one [1], empty to cancel: "},
+		{3, true, true, "select one [1-3], empty for the marked one, 0 to cancel: "},
+		{1, false, false, "select any [ie: 1], empty to cancel: "},
+		{2, false, false, "select any [ie: 1,2], empty to cancel: "},
+		{5, false, true, "select any [ie: 1,3-5], empty for the marked ones, 0 to cancel: "},
+	}
+	for _, test := range tests {
+		if got := choicePrompt(test.count, test.one, test.marked); got != test.expected {
+			t.Logf("expected %q got %q", test.expected, got)
+			t.Fail()
+		}
+	}
+}
+
+func TestPlainChoiceMenuShowsDescriptions(t *testing.T) {
+	out := &bytes.Buffer{}
+	p := NewPlain(strings.NewReader("\n"), out)
+	items := []interfaces.Item{
+		{Name: "master"},
+		{Name: "fix_1.2_1234", Description: "2 ahead, 5 behind"},
+	}
+	if _, err := p.ChoiceMenu(items, true, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "   1) master\n") {
+		t.Fatalf("expected no description for master, got %q", out.String())
+	}
+	if !strings.Contains(out.String(), "   2) fix_1.2_1234  2 ahead, 5 behind\n") {
+		t.Fatalf("expected the description to be shown, got %q", out.String())
+	}
+}
@@ -4,75 +4,239 @@
 package cli
 
 import (
+	"fmt"
+	"os"
+	"strings"
+
 	"github.com/juju/errors"
+	"github.com/perrito666/got/interfaces"
 	gc "github.com/rthornton128/goncurses"
 )
 
+const (
+	// inputMaxLength is the longest text a user can input.
+	inputMaxLength = 256
+	// descriptionSeparator goes between the name and description of items.
+	descriptionSeparator = "  "
+)
+
 // UI groups methods that pertain to User Interfaces.
 type UI struct {
 }
 
 // ChoiceMenu presents the user with a set of choices to toggle using
-// a ncurses togle-able menu.
-// TODO(perrito) current should be a list and used
-func (*UI) ChoiceMenu(items []string, one bool, curent int) ([]int, error) {
+// a ncurses menu, typing narrows the choices by fuzzy matching them.
+// The items in current start selected and under the cursor.
+func (*UI) ChoiceMenu(items []interfaces.Item, one bool, current []int) ([]int, error) {
 	stdscr, err := gc.Init()
 	defer gc.End()
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
 
-	gc.StartColor()
 	gc.Raw(true)
 	gc.Echo(false)
 	gc.Cursor(0)
 	stdscr.Keypad(true)
 
-	menuItems := make([]*gc.MenuItem, len(items))
-	for i, val := range items {
-		menuItems[i], _ = gc.NewItem(val, "")
-		defer menuItems[i].Free()
-	}
-
-	// create the menu
-	menu, err := gc.NewMenu(menuItems)
-	defer menu.Free()
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-
-	// This in true works very weirdly.
-	menu.Option(gc.O_ONEVALUE, false)
-	menu.Option(gc.O_SHOWDESC, true)
-
-	menu.Post()
-	defer menu.UnPost()
-
+	m := newMenu(items, one, current)
 	for {
-		gc.Update()
+		page := drawMenu(stdscr, m)
 		ch := stdscr.GetChar()
 
 		switch ch {
 		// This is Esc at least in linux vtx.... why is it not a const?
 		case 27:
 			return nil, nil
-		case ' ':
-			if !one {
-				menu.Driver(gc.REQ_TOGGLE)
-			}
 		case gc.KEY_RETURN, gc.KEY_ENTER:
-			if one {
-				menu.Driver(gc.REQ_TOGGLE)
+			// there is nothing to choose if the filter matches nothing.
+			if _, ok := m.current(); one && !ok {
+				continue
+			}
+			return m.chosen(), nil
+		case gc.KEY_TAB:
+			if !one {
+				m.toggle()
 			}
-			var list []int
-			for i, item := range menu.Items() {
-				if item.Value() {
-					list = append(list, i)
-				}
+		case ' ':
+			if !one {
+				m.toggle()
+				continue
 			}
-			return list, nil
+			m.typeRune(' ')
+		case gc.KEY_UP:
+			m.move(-1)
+		case gc.KEY_DOWN:
+			m.move(1)
+		case gc.KEY_PAGEUP:
+			m.move(-page)
+		case gc.KEY_PAGEDOWN:
+			m.move(page)
+		case gc.KEY_HOME:
+			m.moveTo(0)
+		case gc.KEY_END:
+			m.moveTo(len(m.matches) - 1)
+		// Backspace is sent as DEL or ^H by many terminals.
+		case gc.KEY_BACKSPACE, 127, 8:
+			m.backspace()
 		default:
-			menu.Driver(gc.DriverActions[ch])
+			if ch >= ' ' && ch < 127 {
+				m.typeRune(rune(ch))
+			}
+		}
+	}
+}
+
+// drawMenu renders the filter on the first row and as many matches as
+// fit below it, it returns the number of matches shown.
+func drawMenu(stdscr *gc.Window, m *menu) int {
+	rows, cols := stdscr.MaxYX()
+	height := rows - 1
+	m.scroll(height)
+
+	stdscr.Erase()
+	stdscr.MovePrintf(0, 0, "> %s", string(m.query))
+	status := fmt.Sprintf("%d/%d", len(m.matches), len(m.items))
+	if cols > len(m.query)+len(status)+3 {
+		stdscr.MovePrint(0, cols-len(status)-1, status)
+	}
+	for row := 0; row < height && m.offset+row < len(m.matches); row++ {
+		position := m.offset + row
+		drawItem(stdscr, row+1, cols, m, m.matches[position], position == m.cursor)
+	}
+	stdscr.Refresh()
+	return height
+}
+
+// drawItem renders one match followed by its description, highlighting
+// the characters that matched the filter and the whole row if it is
+// under the cursor.
+func drawItem(stdscr *gc.Window, row, cols int, m *menu, item match, underCursor bool) {
+	if underCursor {
+		stdscr.AttrOn(gc.A_REVERSE)
+		defer stdscr.AttrOff(gc.A_REVERSE)
+	}
+	prefix := "  "
+	if !m.one {
+		prefix = "[ ] "
+		if m.selected[item.index] {
+			prefix = "[x] "
+		}
+	}
+	stdscr.MovePrint(row, 0, prefix)
+
+	matched := make(map[int]bool, len(item.positions))
+	for _, position := range item.positions {
+		matched[position] = true
+	}
+	width := cols - len(prefix) - 1
+	name := []rune(m.items[item.index].Name)
+	for i, r := range name {
+		if i >= width {
+			return
+		}
+		if matched[i] {
+			stdscr.AttrOn(gc.A_BOLD | gc.A_UNDERLINE)
+		}
+		stdscr.Print(string(r))
+		if matched[i] {
+			stdscr.AttrOff(gc.A_BOLD | gc.A_UNDERLINE)
+		}
+	}
+
+	description := []rune(m.items[item.index].Description)
+	width -= len(name) + len(descriptionSeparator)
+	if len(description) == 0 || width <= 0 {
+		return
+	}
+	if len(description) > width {
+		description = description[:width]
+	}
+	stdscr.AttrOn(gc.A_DIM)
+	stdscr.Print(descriptionSeparator + string(description))
+	stdscr.AttrOff(gc.A_DIM)
+}
+
+// Input prompts the user for a line of text.
+func (*UI) Input(prompt string) (string, error) {
+	if !isTerminal(os.Stdin) {
+		return stdLine.Input(prompt)
+	}
+	stdscr, err := initInput()
+	defer gc.End()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return readString(stdscr, prompt)
+}
+
+// Confirm asks the user a yes or no question, anything but yes is a no.
+func (*UI) Confirm(prompt string) (bool, error) {
+	if !isTerminal(os.Stdin) {
+		return stdLine.Confirm(prompt)
+	}
+	stdscr, err := gc.Init()
+	defer gc.End()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+
+	gc.Raw(true)
+	gc.Echo(false)
+	stdscr.Keypad(true)
+
+	stdscr.Print(prompt + " [y/N] ")
+	stdscr.Refresh()
+	switch stdscr.GetChar() {
+	case 'y', 'Y':
+		return true, nil
+	}
+	return false, nil
+}
+
+// ValidatedInput prompts the user for a line of text until it
+// passes validation.
+func (*UI) ValidatedInput(prompt string, validate interfaces.Validator) (string, error) {
+	if !isTerminal(os.Stdin) {
+		return stdLine.ValidatedInput(prompt, validate)
+	}
+	stdscr, err := initInput()
+	defer gc.End()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	for {
+		text, err := readString(stdscr, prompt)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		if err := validate(text); err != nil {
+			stdscr.Println(err)
+			continue
 		}
+		return text, nil
+	}
+}
+
+// initInput starts ncurses to read text echoing what the user types,
+// gc.End must be called when done even on error.
+func initInput() (*gc.Window, error) {
+	stdscr, err := gc.Init()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	gc.Echo(true)
+	gc.Cursor(1)
+	stdscr.Keypad(true)
+	return stdscr, nil
+}
+
+func readString(stdscr *gc.Window, prompt string) (string, error) {
+	stdscr.Print(prompt)
+	stdscr.Refresh()
+	text, err := stdscr.GetString(inputMaxLength)
+	if err != nil {
+		return "", errors.Trace(err)
 	}
+	return strings.TrimSpace(text), nil
 }
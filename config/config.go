@@ -0,0 +1,138 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/perrito666/got/git"
+	"gopkg.in/yaml.v2"
+)
+
+// FileName is the name of the got configuration file, it is read
+// from the root of the repository.
+const FileName = ".got.yml"
+
+// File holds the contents of the got configuration file, ie:
+//
+//	branch:
+//	  template: "{type}/{target}/{id}"
+//	types:
+//	  fix:
+//	    template: "bugfix/{target}/{id}-{slug}"
+//	  chore:
+//	    description: "maintenance that fixes no bug"
+type File struct {
+	Branch Branch          `yaml:"branch"`
+	Types  map[string]Type `yaml:"types"`
+}
+
+// Branch holds the settings that apply to all work branches.
+type Branch struct {
+	Template string `yaml:"template"`
+}
+
+// Type holds the settings of one type of work branch.
+type Type struct {
+	Template    string `yaml:"template"`
+	Description string `yaml:"description"`
+}
+
+// Load reads the configuration file of the current repository, a
+// missing file yields an empty configuration, as does being outside
+// of a working tree.
+func Load(newGit git.CompatibleConstructor) (*File, error) {
+	c := newGit("rev-parse", []string{"--show-toplevel"})
+	cmd, err := c.Git()
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot create git command caller")
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		// there is no working tree to hold a configuration file.
+		return &File{}, nil
+	}
+	return Read(filepath.Join(strings.TrimSpace(string(out)), FileName))
+}
+
+// Read reads the configuration file at path, a missing file yields
+// an empty configuration.
+func Read(path string) (*File, error) {
+	f := &File{}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot read %q", path)
+	}
+	if err := yaml.Unmarshal(data, f); err != nil {
+		return nil, errors.Annotatef(err, "cannot parse %q", path)
+	}
+	return f, nil
+}
+
+// BranchTemplate returns the branch name template for the given type of
+// work branch, in order of precedence from the got.<type>.template and
+// got.branch.template git config keys and then from the configuration
+// file. It returns an empty string if none is set.
+func BranchTemplate(newGit git.CompatibleConstructor, branchType string) (string, error) {
+	for _, key := range []string{"got." + branchType + ".template", "got.branch.template"} {
+		template, err := git.ConfigGet(newGit, key)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		if template != "" {
+			return template, nil
+		}
+	}
+	f, err := Load(newGit)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if template := f.Types[branchType].Template; template != "" {
+		return template, nil
+	}
+	return f.Branch.Template, nil
+}
+
+// WorkTypes returns the names of the types of work branch declared in
+// the repository, either as multiple got.type git config keys or under
+// types in the configuration file.
+func WorkTypes(newGit git.CompatibleConstructor) ([]string, error) {
+	declared, err := git.ConfigGetAll(newGit, "got.type")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	f, err := Load(newGit)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return f.typeNames(declared), nil
+}
+
+// typeNames returns the sorted union of declared and the types in f.
+func (f *File) typeNames(declared []string) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, name := range declared {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for name := range f.Types {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
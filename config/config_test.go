@@ -0,0 +1,67 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadMissingFile(t *testing.T) {
+	f, err := Read(filepath.Join(os.TempDir(), "got-does-not-exist", FileName))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.Branch.Template != "" || len(f.Types) != 0 {
+		t.Fatalf("expected an empty configuration, got %#v", f)
+	}
+}
+
+func TestRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "got")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, FileName)
+	content := `
+branch:
+  template: "{type}/{target}/{id}"
+types:
+  fix:
+    template: "bugfix/{target}/{id}-{slug}"
+`
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Read(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.Branch.Template != "{type}/{target}/{id}" {
+		t.Fatalf("unexpected branch template %q", f.Branch.Template)
+	}
+	if f.Types["fix"].Template != "bugfix/{target}/{id}-{slug}" {
+		t.Fatalf("unexpected fix template %q", f.Types["fix"].Template)
+	}
+}
+
+func TestTypeNames(t *testing.T) {
+	f := &File{Types: map[string]Type{
+		"fix":   {Template: "bugfix/{target}/{id}"},
+		"spike": {},
+	}}
+	names := f.typeNames([]string{"hotfix", "chore", "spike"})
+	expected := []string{"chore", "fix", "hotfix", "spike"}
+	if len(names) != len(expected) {
+		t.Fatalf("expected %v got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Fatalf("expected %v got %v", expected, names)
+		}
+	}
+}
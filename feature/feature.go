@@ -10,9 +10,13 @@ import (
 	"github.com/juju/errors"
 	"github.com/perrito666/got/cli"
 	"github.com/perrito666/got/feature/newfeature"
-	"github.com/perrito666/got/feature/work"
 	"github.com/perrito666/got/git"
 	"github.com/perrito666/got/registry"
+	"github.com/perrito666/got/util"
+	"github.com/perrito666/got/workitem"
+	"github.com/perrito666/got/workitem/clean"
+	"github.com/perrito666/got/workitem/finish"
+	"github.com/perrito666/got/workitem/work"
 )
 
 var flagSet *flag.FlagSet
@@ -20,6 +24,8 @@ var flagSet *flag.FlagSet
 type config struct {
 	abbreviateList bool
 	interactive    bool
+
+	workitem.Flags
 }
 
 var callConfig = &config{}
@@ -33,6 +39,7 @@ func init() {
 	flagSet.BoolVar(&callConfig.abbreviateList, "s", false, shortDescription)
 	flagSet.BoolVar(&callConfig.abbreviateList, "short", false, shortDescription)
 	flagSet.BoolVar(&callConfig.interactive, "i", false, "prompt the feature with a menu.")
+	callConfig.Flags.Add(flagSet)
 	newfeature.Flags(flagSet)
 }
 
@@ -44,6 +51,42 @@ func NewFeatureCommand() (registry.Command, error) {
 var usageDoc = `
 usage: 
 got feature: prints this help
+
+feature assumes that you have a project that has one or several maintenance
+branches such as: master, 1.2, 1.3 and that features are developed in branches
+targeting one of them.
+
+feature available subcommands:
+
+  new [-target target_branch] [-name name | name]
+    will create a new branch for the feature, if no target is given the default
+    (got.feature.default git config key) is used or you will be prompted.
+    branches are named feature_<target>_<name> unless a template is configured
+    in the got.feature.template git config key or in the .got.yml file.
+
+  work [-s|--short] [-r|--remote]
+  list [-s|--short] [-r|--remote]
+    will list the features and the targets for which they exist, with -r the
+    feature branches that only exist in remotes are listed too.
+
+  work [-i] [-r|--remote] <name>
+    will checkout the branch for that feature, prompting if there is more than
+    one, with -r a local branch is created to track one that only exists in a
+    remote.
+
+  finish [--strategy merge|squash|rebase] [--delete-remote] [--force] [name]
+    will merge the feature branch, or the current one, into its target unless
+    it already is, delete it and checkout the target.
+    the strategy can also be set in the got.feature.strategy git config key,
+    merge is used by default. with --delete-remote the branch is deleted from
+    its remote too. it refuses to run with uncommitted changes unless --force
+    is given.
+
+  clean [--delete-remote]
+    will offer to delete the feature branches already merged into their targets,
+    squashed ones included, with --delete-remote they are deleted from their
+    remote too.
+
 `
 
 // Command provides the "feature" subcommand to got.
@@ -62,24 +105,44 @@ func (b *Command) Run(args []string) error {
 		return errors.Annotate(err, "error parsing arguments")
 	}
 	switch subC {
-	case "work":
+	case "work", "list":
 		// TODO (perrito666) make command an interface
 		// make command simpler and Flags instead
 		w := work.Command{
+			Type:        util.FeatureType,
 			Args:        flagSet.Args(),
 			Interactive: callConfig.interactive,
 			Short:       callConfig.abbreviateList,
-			UI:          &cli.UI{},
-			NewGit:      git.New,
+			Remote:      callConfig.Remote,
+			UI:          cli.New(),
+			NewGit:      git.Backend,
+		}
+		if subC == "list" {
+			return w.List()
 		}
 		return w.Handle()
-	case "new":
-		n := newfeature.Command{
-			Args:   flagSet.Args(),
-			UI:     &cli.UI{},
-			NewGit: git.New,
+	case "clean":
+		c := clean.Command{
+			Type:         util.FeatureType,
+			DeleteRemote: callConfig.DeleteRemote,
+			UI:           cli.New(),
+			NewGit:       git.Backend,
+		}
+		return c.Handle()
+	case "finish":
+		f := finish.Command{
+			Type:         util.FeatureType,
+			Args:         flagSet.Args(),
+			Base:         "",
+			Strategy:     callConfig.Strategy,
+			DeleteRemote: callConfig.DeleteRemote,
+			Force:        callConfig.Force,
+			UI:           cli.New(),
+			NewGit:       git.Backend,
 		}
-		return n.Handle()
+		return f.Handle()
+	case "new":
+		return newfeature.NewCommand(flagSet.Args(), cli.New(), git.Backend).Handle()
 	}
-	return nil
+	return errors.NotSupportedf("%s sub-command %q", "feature", subC)
 }
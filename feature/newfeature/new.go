@@ -5,13 +5,11 @@ package newfeature
 
 import (
 	"flag"
-	"fmt"
-	"strings"
 
-	"github.com/juju/errors"
 	"github.com/perrito666/got/git"
 	"github.com/perrito666/got/interfaces"
 	"github.com/perrito666/got/util"
+	"github.com/perrito666/got/workitem/newitem"
 )
 
 type config struct {
@@ -27,30 +25,17 @@ func Flags(flagSet *flag.FlagSet) {
 	flagSet.StringVar(&callConfig.featureTarget, "target", "", "the target of the feature, this branch should already exist.")
 }
 
-// Command holds the configuration and methods required for the new
-// sub-command.
-type Command struct {
-	Args   []string
-	UI     interfaces.UI
-	NewGit git.CompatibleConstructor
-}
-
-// Handle is the entry point for the Work sub-command.
-func (w *Command) Handle() error {
-	if callConfig.featureName == "" && callConfig.featureTarget == "" {
-
-		// TODO(perrito666) create an interactive mode for feature
-		fmt.Println("please specify target branch and branch name")
-		return nil
-	}
-	name := callConfig.featureName
-	for _, invalidChar := range []string{",", "-", ".", " "} {
-		name = strings.Replace(name, invalidChar, "_", -1)
+// NewCommand returns the new sub-command for features, the name and
+// target flags take the place of the name argument and the base.
+func NewCommand(args []string, ui interfaces.UI, newGit git.CompatibleConstructor) *newitem.Command {
+	if callConfig.featureName != "" {
+		args = []string{callConfig.featureName}
 	}
-	created, err := util.NewBranch(w.NewGit, util.FeatureType, callConfig.featureTarget, name)
-	if err != nil {
-		return errors.Annotate(err, "cannot create new branch")
+	return &newitem.Command{
+		Type:   util.FeatureType,
+		Args:   args,
+		Base:   callConfig.featureTarget,
+		UI:     ui,
+		NewGit: newGit,
 	}
-	fmt.Printf("now working in %q \n", created)
-	return nil
 }
@@ -0,0 +1,46 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package newfeature
+
+import (
+	"flag"
+	"testing"
+
+	gtesting "github.com/perrito666/got/testing"
+	"github.com/perrito666/got/util"
+)
+
+func TestNewCommandUsesFlags(t *testing.T) {
+	tests := map[string]struct {
+		flags []string
+		args  []string
+		name  string
+		base  string
+	}{
+		"flags":     {[]string{"-name", "login flow", "-target", "1.2"}, []string{"ignored"}, "login flow", "1.2"},
+		"arguments": {nil, []string{"login flow"}, "login flow", ""},
+		"nothing":   {nil, nil, "", ""},
+	}
+	for name, test := range tests {
+		callConfig = &config{}
+		flagSet := flag.NewFlagSet("feature", flag.ContinueOnError)
+		Flags(flagSet)
+		if err := flagSet.Parse(test.flags); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		c := NewCommand(test.args, &gtesting.FakeUI{}, gtesting.New)
+		if c.Type != util.FeatureType || c.Base != test.base {
+			t.Logf("%s: expected a %s based on %q got a %s based on %q", name, util.FeatureType, test.base, c.Type, c.Base)
+			t.Fail()
+		}
+		given := ""
+		if len(c.Args) > 0 {
+			given = c.Args[0]
+		}
+		if given != test.name {
+			t.Logf("%s: expected name %q got %q", name, test.name, given)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package git
+
+// SCMDConfig is the git sub command for config.
+const SCMDConfig string = "config"
+
+// ConfigGet is a shortcut for Repo.ConfigGet.
+func ConfigGet(newGit CompatibleConstructor, key string) (string, error) {
+	return NewRepo(newGit).ConfigGet(key)
+}
+
+// ConfigSet is a shortcut for Repo.ConfigSet.
+func ConfigSet(newGit CompatibleConstructor, key, value string) error {
+	return NewRepo(newGit).ConfigSet(key, value)
+}
+
+// ConfigUnset is a shortcut for Repo.ConfigUnset.
+func ConfigUnset(newGit CompatibleConstructor, key string) error {
+	return NewRepo(newGit).ConfigUnset(key)
+}
+
+// ConfigGetAll is a shortcut for Repo.ConfigGetAll.
+func ConfigGetAll(newGit CompatibleConstructor, key string) ([]string, error) {
+	return NewRepo(newGit).ConfigGetAll(key)
+}
@@ -0,0 +1,274 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package git
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// readOnlyCommands never change the repository.
+var readOnlyCommands = map[string]bool{
+	"cat-file":     true,
+	"cherry":       true,
+	"describe":     true,
+	"diff":         true,
+	"for-each-ref": true,
+	"log":          true,
+	"ls-files":     true,
+	"merge-base":   true,
+	"patch-id":     true,
+	"rev-list":     true,
+	"rev-parse":    true,
+	"show":         true,
+	"show-ref":     true,
+	"status":       true,
+	"version":      true,
+}
+
+// branchListFlags are the flags of git branch that make it list the
+// branches, those that filter take the rest of the arguments.
+var branchListFlags = map[string]bool{
+	"-a": true, "--all": true,
+	"-r": true, "--remotes": true,
+	"-l": true, "--list": true,
+	"-v": true, "-vv": true, "--verbose": true,
+	"--show-current": true,
+	"--merged":       true, "--no-merged": true,
+	"--contains": true, "--no-contains": true,
+	"--points-at": true,
+	"--format":    true, "--sort": true,
+}
+
+// branchChangeFlags are the flags of git branch that change branches,
+// they win over the listing ones.
+var branchChangeFlags = map[string]bool{
+	"-d": true, "-D": true, "--delete": true,
+	"-m": true, "-M": true, "--move": true,
+	"-c": true, "-C": true, "--copy": true,
+	"-f": true, "--force": true,
+	"-t": true, "--track": true, "--no-track": true,
+	"-u": true, "--set-upstream-to": true, "--unset-upstream": true,
+	"--edit-description": true,
+	"--create-reflog":    true,
+}
+
+// tagListFlags are the flags of git tag that make it list the tags.
+var tagListFlags = map[string]bool{
+	"-l": true, "--list": true,
+	"-n":         true,
+	"--contains": true, "--no-contains": true,
+	"--merged": true, "--no-merged": true,
+	"--points-at": true,
+	"--format":    true, "--sort": true,
+}
+
+// tagChangeFlags are the flags of git tag that change tags, they win
+// over the listing ones.
+var tagChangeFlags = map[string]bool{
+	"-d": true, "--delete": true,
+	"-a": true, "--annotate": true,
+	"-s": true, "--sign": true,
+	"-u": true, "--local-user": true,
+	"-f": true, "--force": true,
+	"-m": true, "--message": true,
+	"-F": true, "--file": true,
+}
+
+// readOnly returns true if the given git command line, sub command
+// first, does not change the repository.
+func readOnly(args []string) bool {
+	if len(args) == 0 {
+		return true
+	}
+	switch args[0] {
+	case SCMDConfig:
+		return configReadOnly(args[1:])
+	case "remote":
+		// without a sub command git remote lists the remotes.
+		sub := subCommandOf(args[1:], "-v", "--verbose")
+		return sub == "" || sub == "get-url" || sub == "show"
+	case "stash":
+		sub := subCommandOf(args[1:])
+		return sub == "list" || sub == "show"
+	case "worktree":
+		return subCommandOf(args[1:]) == "list"
+	case "symbolic-ref":
+		// with a single name it reads where the name points to.
+		names := 0
+		for _, arg := range args[1:] {
+			if arg == "-d" || arg == "--delete" || arg == "-m" {
+				return false
+			}
+			if !strings.HasPrefix(arg, "-") {
+				names++
+			}
+		}
+		return names == 1
+	case "tag":
+		return listing(args[1:], tagListFlags, tagChangeFlags)
+	case SCMDBranch:
+		return listing(args[1:], branchListFlags, branchChangeFlags)
+	}
+	return readOnlyCommands[args[0]]
+}
+
+// listing returns true if the arguments of git branch or git tag make
+// it list, which it does without arguments too.
+func listing(args []string, listFlags, changeFlags map[string]bool) bool {
+	list := len(args) == 0
+	for _, arg := range args {
+		flag := strings.SplitN(arg, "=", 2)[0]
+		if changeFlags[flag] {
+			return false
+		}
+		list = list || listFlags[flag]
+	}
+	return list
+}
+
+// configReadFlags are the flags of git config that make it read.
+var configReadFlags = map[string]bool{
+	"--get": true, "--get-all": true, "--get-regexp": true, "--get-urlmatch": true,
+	"--get-color": true, "--get-colorbool": true,
+	"-l": true, "--list": true,
+}
+
+// configOptionFlags are the flags of git config that neither read nor
+// change.
+var configOptionFlags = map[string]bool{
+	"--global": true, "--system": true, "--local": true, "--worktree": true,
+	"--bool": true, "--int": true, "--path": true, "--type": true,
+	"-z": true, "--null": true, "--show-origin": true, "--show-scope": true,
+	"--includes": true, "--no-includes": true,
+}
+
+// configValueFlags are the flags of git config that take the next
+// argument as their value.
+var configValueFlags = map[string]bool{
+	"-f": true, "--file": true, "--blob": true, "--type": true, "--default": true,
+}
+
+// configReadOnly returns true if the arguments of git config make it
+// read, either with a flag that does or with a key and no value.
+func configReadOnly(args []string) bool {
+	names := 0
+	for i := 0; i < len(args); i++ {
+		flag := strings.SplitN(args[i], "=", 2)[0]
+		switch {
+		case configReadFlags[flag]:
+			return true
+		case configValueFlags[args[i]]:
+			i++
+		case !strings.HasPrefix(flag, "-"):
+			names++
+		case !configOptionFlags[flag] && !configValueFlags[flag]:
+			// --unset, --add, --replace-all and the like.
+			return false
+		}
+	}
+	return names == 1
+}
+
+// subCommandOf returns the first of args that is not a flag, skipping
+// those in flags, or an empty string if there is none and no other flag.
+func subCommandOf(args []string, flags ...string) string {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			return arg
+		}
+		known := false
+		for _, flag := range flags {
+			known = known || flag == arg
+		}
+		if !known {
+			return arg
+		}
+	}
+	return ""
+}
+
+// DryRun is true when got was asked to print the git commands that would
+// change the repository instead of running them.
+var DryRun bool
+
+// Recorder prints the git commands that would change the repository
+// instead of running them, the rest do run so that what got decides to
+// do is what it would really do.
+type Recorder struct {
+	out io.Writer
+	// Skipped holds the command lines that were not run.
+	Skipped [][]string
+}
+
+// NewRecorder returns a Recorder that prints to out.
+func NewRecorder(out io.Writer) *Recorder {
+	return &Recorder{out: out}
+}
+
+// Craft wraps craft so the commands that change the repository are
+// recorded, it is meant to be used as Options.Craft.
+func (r *Recorder) Craft(craft CommandCraftFunc) CommandCraftFunc {
+	return func(opts Options, args []string) ExecCmd {
+		if readOnly(args) {
+			return craft(opts, args)
+		}
+		return &recordedCmd{recorder: r, opts: opts, args: args}
+	}
+}
+
+func (r *Recorder) record(opts Options, args []string) {
+	r.Skipped = append(r.Skipped, args)
+	words := []string{}
+	for _, env := range opts.Env {
+		words = append(words, shellQuote(env))
+	}
+	words = append(words, CMDGit)
+	if opts.Dir != "" {
+		words = append(words, "-C", shellQuote(opts.Dir))
+	}
+	for _, arg := range args {
+		words = append(words, shellQuote(arg))
+	}
+	fmt.Fprintf(r.out, "would run: %s\n", strings.Join(words, " "))
+}
+
+// recordedCmd is the ExecCmd of a command that is not run.
+type recordedCmd struct {
+	recorder *Recorder
+	opts     Options
+	args     []string
+}
+
+// Run implements ExecCmd
+func (c *recordedCmd) Run() error {
+	c.recorder.record(c.opts, c.args)
+	return nil
+}
+
+// Output implements ExecCmd
+func (c *recordedCmd) Output() ([]byte, error) {
+	c.recorder.record(c.opts, c.args)
+	return nil, nil
+}
+
+// shellQuote returns s quoted for a POSIX shell, it is returned as is
+// if it needs no quoting.
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := true
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("_@%+=:,./-", c)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,109 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package git
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	tests := map[string]struct {
+		args     []string
+		readOnly bool
+	}{
+		"log":           {[]string{"log", "--format=%H"}, true},
+		"config get":    {[]string{"config", "--get", "got.fix.default"}, true},
+		"config set":    {[]string{"config", "got.fix.default", "1.2"}, false},
+		"config key":    {[]string{"config", "user.name"}, true},
+		"config file":   {[]string{"config", "-f", ".got.yml", "user.name"}, true},
+		"config unset":  {[]string{"config", "--unset", "got.port.source"}, false},
+		"config add":    {[]string{"config", "--add", "got.type", "chore"}, false},
+		"remote url":    {[]string{"remote", "get-url", "origin"}, true},
+		"remote show":   {[]string{"remote", "show", "origin"}, true},
+		"remote rm":     {[]string{"remote", "remove", "fork"}, false},
+		"tag":           {[]string{"tag"}, true},
+		"tag list":      {[]string{"tag", "-l", "v1.*"}, true},
+		"tag create":    {[]string{"tag", "v1.2"}, false},
+		"tag delete":    {[]string{"tag", "-d", "-l", "v1.2"}, false},
+		"stash list":    {[]string{"stash", "list"}, true},
+		"stash":         {[]string{"stash"}, false},
+		"stash pop":     {[]string{"stash", "pop"}, false},
+		"worktree list": {[]string{"worktree", "list"}, true},
+		"worktree add":  {[]string{"worktree", "add", "../x"}, false},
+		"symbolic-ref":  {[]string{"symbolic-ref", "--short", "HEAD"}, true},
+		"symbolic set":  {[]string{"symbolic-ref", "HEAD", "refs/heads/x"}, false},
+		"branch list":   {[]string{"branch", "-a"}, true},
+		"branch":        {[]string{"branch", "fix_1.2_1"}, false},
+		"branch -D":     {[]string{"branch", "-D", "fix_1.2_1"}, false},
+		"branch plain":  {[]string{"branch"}, true},
+		"list pattern":  {[]string{"branch", "--list", "fix_*"}, true},
+		"merged":        {[]string{"branch", "--merged", "master"}, true},
+		"contains":      {[]string{"branch", "-r", "--contains=abc123"}, true},
+		"delete merged": {[]string{"branch", "-d", "--merged", "master"}, false},
+		"commit-tree":   {[]string{"commit-tree", "x^{tree}", "-p", "y"}, false},
+		"remote":        {[]string{"remote", "-v"}, true},
+		"remote add":    {[]string{"remote", "add", "fork", "url"}, false},
+		"patch-id":      {[]string{"patch-id", "--stable"}, true},
+		"checkout":      {[]string{"checkout", "master"}, false},
+		"push":          {[]string{"push", "origin", "--delete", "x"}, false},
+		"no arguments":  {nil, true},
+	}
+	for name, test := range tests {
+		if got := readOnly(test.args); got != test.readOnly {
+			t.Logf("%s: expected read only %v for %q got %v", name, test.readOnly, test.args, got)
+			t.Fail()
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"fix_1.2_1":       "fix_1.2_1",
+		"":                "''",
+		"squashed fix":    "'squashed fix'",
+		"it's":            `'it'\''s'`,
+		"x^{tree}":        "'x^{tree}'",
+		"--format=%H%x00": "--format=%H%x00",
+	}
+	for s, expected := range tests {
+		if got := shellQuote(s); got != expected {
+			t.Logf("expected %q quoted as %q got %q", s, expected, got)
+			t.Fail()
+		}
+	}
+}
+
+func TestRecorderSkipsChanges(t *testing.T) {
+	out := &bytes.Buffer{}
+	recorder := NewRecorder(out)
+	ran := [][]string{}
+	craft := func(_ Options, args []string) ExecCmd {
+		ran = append(ran, args)
+		return &fakeCmd{}
+	}
+	newGit := NewWithOptions(Options{
+		Craft: func(CommandCraftFunc) CommandCraftFunc { return recorder.Craft(craft) },
+	})
+	if err := newGit("checkout", []string{"-b", "fix_1.2_it's", "1.2"}).Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cmd, err := newGit("rev-parse", []string{"--abbrev-ref", "HEAD"}).Git()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := cmd.Output(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ran) != 1 || ran[0][0] != "rev-parse" {
+		t.Fatalf("expected only rev-parse to run, ran %q", ran)
+	}
+	if len(recorder.Skipped) != 1 {
+		t.Fatalf("expected one skipped command got %q", recorder.Skipped)
+	}
+	expected := "would run: git checkout -b 'fix_1.2_it'\\''s' 1.2\n"
+	if out.String() != expected {
+		t.Fatalf("expected %q got %q", expected, out.String())
+	}
+}
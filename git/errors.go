@@ -0,0 +1,174 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// Error is returned when git fails, it holds what git was called with
+// and what it said about it.
+type Error struct {
+	SubCommand string
+	Args       []string
+	// ExitCode is the exit code of git or -1 if it could not run.
+	ExitCode int
+	// Stderr holds what git wrote to its standard error.
+	Stderr string
+	// Conflicted is true if git left paths with unresolved conflicts.
+	Conflicted bool
+	// Err is the underlying error returned by exec.
+	Err error
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	call := strings.TrimSpace(strings.Join(append([]string{CMDGit, e.SubCommand}, e.Args...), " "))
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		return fmt.Sprintf("%s failed: %s", call, stderr)
+	}
+	return fmt.Sprintf("%s failed: %v", call, e.Err)
+}
+
+// newError returns an *Error for the given exec error, or nil if there
+// was none.
+func newError(c *Call, err error) error {
+	if err == nil {
+		return nil
+	}
+	gitErr := &Error{
+		SubCommand: c.subCommand,
+		Args:       c.args,
+		ExitCode:   -1,
+		Err:        err,
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		gitErr.ExitCode = exitErr.ExitCode()
+		gitErr.Stderr = string(exitErr.Stderr)
+	}
+	return gitErr
+}
+
+// errorCmd is an ExecCmd that returns *Error when git fails.
+type errorCmd struct {
+	cmd  ExecCmd
+	call *Call
+}
+
+// Run implements ExecCmd, standard output is left going to the terminal
+// so pagers, colours and editors work while standard error is also kept
+// to describe a failure.
+func (e *errorCmd) Run() error {
+	stderr := &bytes.Buffer{}
+	if cmd, ok := e.cmd.(*exec.Cmd); ok {
+		if cmd.Stderr == nil {
+			cmd.Stderr = stderr
+		} else {
+			cmd.Stderr = io.MultiWriter(cmd.Stderr, stderr)
+		}
+	}
+	err := newError(e.call, e.cmd.Run())
+	if gitErr, ok := err.(*Error); ok && gitErr.ExitCode > 0 {
+		if gitErr.Stderr == "" {
+			gitErr.Stderr = stderr.String()
+		}
+		// merge reports conflicts in its standard output, so they are
+		// looked for in the repository instead.
+		gitErr.Conflicted = e.call.conflicted()
+	}
+	return err
+}
+
+// Output implements ExecCmd.
+func (e *errorCmd) Output() ([]byte, error) {
+	out, err := e.cmd.Output()
+	return out, newError(e.call, err)
+}
+
+// AsError returns the *Error that caused err, if any.
+func AsError(err error) (*Error, bool) {
+	gitErr, ok := errors.Cause(err).(*Error)
+	return gitErr, ok
+}
+
+// ExitCode returns the exit code of the git call that caused err or -1
+// if it was not caused by git exiting.
+func ExitCode(err error) int {
+	if gitErr, ok := AsError(err); ok {
+		return gitErr.ExitCode
+	}
+	return -1
+}
+
+// says returns true if err was caused by git saying any of messages,
+// which git only writes in english unless translated.
+func says(err error, messages ...string) bool {
+	gitErr, ok := AsError(err)
+	if !ok {
+		return false
+	}
+	said := strings.ToLower(gitErr.Stderr)
+	for _, message := range messages {
+		if strings.Contains(said, strings.ToLower(message)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotARepository returns true if err was caused by calling git
+// outside of a repository.
+func IsNotARepository(err error) bool {
+	return says(err, "not a git repository")
+}
+
+// IsBranchExists returns true if err was caused by creating a branch
+// that already exists.
+func IsBranchExists(err error) bool {
+	gitErr, ok := AsError(err)
+	return ok && says(gitErr, "already exists") && says(gitErr, "a branch named")
+}
+
+// IsMergeConflict returns true if err was caused by a merge, cherry-pick,
+// rebase or similar stopping on conflicts.
+func IsMergeConflict(err error) bool {
+	if gitErr, ok := AsError(err); ok && gitErr.Conflicted {
+		return true
+	}
+	return says(err, "CONFLICT (", "could not apply", "automatic merge failed", "after resolving the conflicts")
+}
+
+// IsDirtyTree returns true if err was caused by uncommitted changes that
+// git refused to touch.
+func IsDirtyTree(err error) bool {
+	return says(err,
+		"would be overwritten by",
+		"please commit your changes or stash them",
+		"you have unstaged changes",
+		"your index contains uncommitted changes",
+		"contains modified or untracked files",
+	)
+}
+
+// FriendlyMessage returns a message for err that tells the user how to
+// get past the known git failures, or the error itself otherwise.
+func FriendlyMessage(err error) string {
+	switch {
+	case IsNotARepository(err):
+		return "this is not a git repository, run got from within one"
+	case IsBranchExists(err):
+		return fmt.Sprintf("%v\nthe branch already exists, use work to switch to it", err)
+	case IsMergeConflict(err):
+		return fmt.Sprintf("%v\ngit stopped on conflicts, solve them and continue or abort", err)
+	case IsDirtyTree(err):
+		return fmt.Sprintf("%v\nthere are uncommitted changes, commit or stash them first", err)
+	}
+	return err.Error()
+}
@@ -0,0 +1,153 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/juju/errors"
+)
+
+func TestErrorClassifiers(t *testing.T) {
+	classifiers := map[string]func(error) bool{
+		"not a repository": IsNotARepository,
+		"branch exists":    IsBranchExists,
+		"merge conflict":   IsMergeConflict,
+		"dirty tree":       IsDirtyTree,
+	}
+	tests := []struct {
+		stderr   string
+		expected string
+	}{
+		{"fatal: not a git repository (or any of the parent directories): .git", "not a repository"},
+		{"fatal: a branch named 'fix_1.2_1' already exists", "branch exists"},
+		{"fatal: A branch named 'fix_1.2_1' already exists.", "branch exists"},
+		{"error: could not apply 1a2b3c... fix\n", "merge conflict"},
+		{"CONFLICT (content): Merge conflict in a.go\nAutomatic merge failed; fix conflicts and then commit the result.", "merge conflict"},
+		{"error: Your local changes to the following files would be overwritten by checkout:", "dirty tree"},
+		{"error: cannot rebase: You have unstaged changes.", "dirty tree"},
+		{"fatal: something else", ""},
+	}
+	for _, test := range tests {
+		err := errors.Annotate(&Error{SubCommand: "x", ExitCode: 1, Stderr: test.stderr}, "context")
+		for name, classifier := range classifiers {
+			if classifier(err) != (name == test.expected) {
+				t.Logf("expected %s to be %v for %q", name, name == test.expected, test.stderr)
+				t.Fail()
+			}
+		}
+	}
+	if IsMergeConflict(errors.New("CONFLICT (content)")) {
+		t.Logf("errors not caused by git should not be classified")
+		t.Fail()
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	err := &Error{SubCommand: "checkout", Args: []string{"-b", "x"}, ExitCode: 128, Stderr: "fatal: boom\n"}
+	if msg := err.Error(); msg != "git checkout -b x failed: fatal: boom" {
+		t.Fatalf("unexpected message %q", msg)
+	}
+	if ExitCode(errors.Trace(err)) != 128 {
+		t.Fatalf("expected the exit code to survive tracing")
+	}
+	if ExitCode(errors.New("other")) != -1 {
+		t.Fatalf("expected -1 for errors not caused by git")
+	}
+}
+
+func TestRunCapturesStderr(t *testing.T) {
+	if _, err := exec.LookPath(CMDGit); err != nil {
+		t.Skip("git is not installed")
+	}
+	newGit := NewWithOptions(Options{Dir: t.TempDir(), Env: []string{"GIT_CEILING_DIRECTORIES=/"}})
+	cmd, err := newGit("status", nil).Git()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, outputErr := cmd.Output()
+	runErr := newGit("status", nil).Run()
+	for name, err := range map[string]error{"Output": outputErr, "Run": runErr} {
+		if !IsNotARepository(err) {
+			t.Logf("%s: expected a not a repository error, got %v", name, err)
+			t.Fail()
+		}
+		if ExitCode(err) != 128 || !strings.Contains(err.Error(), "git status failed: fatal:") {
+			t.Logf("%s: unexpected error %#v", name, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestRunKeepsStdout(t *testing.T) {
+	if _, err := exec.LookPath(CMDGit); err != nil {
+		t.Skip("git is not installed")
+	}
+	c := &Call{subCommand: "version"}
+	cmd, err := c.git(stdCommand)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inner := cmd.(*errorCmd).cmd.(*exec.Cmd)
+	if inner.Stdout != os.Stdout {
+		t.Fatalf("expected git to write straight to stdout, got %T", inner.Stdout)
+	}
+}
+
+func TestRunDetectsConflicts(t *testing.T) {
+	if _, err := exec.LookPath(CMDGit); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	newGit := NewWithOptions(Options{Dir: dir, Env: []string{
+		"GIT_CEILING_DIRECTORIES=/",
+		"GIT_AUTHOR_NAME=got", "GIT_AUTHOR_EMAIL=got@example.com",
+		"GIT_COMMITTER_NAME=got", "GIT_COMMITTER_EMAIL=got@example.com",
+	}})
+	commit := func(content string) {
+		if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte(content), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, args := range [][]string{{"add", "a.go"}, {"commit", "-q", "-m", content}} {
+			if err := newGit(args[0], args[1:]).Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+	if err := newGit("init", []string{"-q", "-b", "master"}).Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	commit("a")
+	if err := newGit(SCMDCheckout, []string{"-q", "-b", "fix_master_1"}).Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	commit("b")
+	if err := newGit(SCMDCheckout, []string{"-q", "master"}).Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	commit("c")
+
+	err := newGit("merge", []string{"--no-ff", "--no-edit", "fix_master_1"}).Run()
+	if !IsMergeConflict(err) {
+		t.Fatalf("expected a merge conflict, got %#v", err)
+	}
+	if err := newGit("merge", []string{"--abort"}).Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("d"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = newGit(SCMDCheckout, []string{"fix_master_1"}).Run()
+	if !IsDirtyTree(err) || IsMergeConflict(err) {
+		t.Fatalf("expected a dirty tree error, got %#v", err)
+	}
+}
@@ -4,7 +4,8 @@
 package git
 
 import (
-	"log"
+	"context"
+	"io"
 	"os"
 	"os/exec"
 
@@ -23,19 +24,45 @@ const (
 	SCMDBranch string = "branch"
 )
 
-func command(args []string) ExecCmd {
-	var cmd *exec.Cmd
-	if args == nil || len(args) == 0 {
-		cmd = exec.Command(CMDGit)
-	} else {
-		cmd = exec.Command(CMDGit, args...)
-	}
+// Options holds the environment git is called in, the zero value
+// calls git in the current directory with the process environment.
+type Options struct {
+	// Context, if not nil, kills git when done before git finishes.
+	Context context.Context
+	// Dir is the directory git runs in, the repository to act on.
+	Dir string
+	// Env holds extra environment variables in the form "key=value",
+	// ie: GIT_DIR, GIT_WORK_TREE or GIT_AUTHOR_NAME.
+	Env []string
+	// Stdin, if not nil, is read by git instead of the process stdin.
+	Stdin io.Reader
+	// Craft, if not nil, wraps how the git commands are created, ie:
+	// Recorder.Craft prints the ones that change the repository instead.
+	Craft func(CommandCraftFunc) CommandCraftFunc
+}
 
+func command(opts Options, args []string) ExecCmd {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cmd := exec.CommandContext(ctx, CMDGit, args...)
+	cmd.Dir = opts.Dir
+	if len(opts.Env) > 0 {
+		cmd.Env = append(os.Environ(), opts.Env...)
+	}
+	if opts.Stdin != nil {
+		cmd.Stdin = opts.Stdin
+	}
 	return cmd
 }
 
-func stdCommand(args []string) ExecCmd {
-	cmd := command(args).(*exec.Cmd)
+func stdCommand(opts Options, args []string) ExecCmd {
+	cmd := command(opts, args).(*exec.Cmd)
+	// some sub commands, like cherry-pick --continue, open an editor.
+	if cmd.Stdin == nil {
+		cmd.Stdin = os.Stdin
+	}
 	cmd.Stderr = os.Stderr
 	cmd.Stdout = os.Stdout
 	return cmd
@@ -49,6 +76,7 @@ var ErrSubCommandEmpty = errors.New("Git sub-command is empty")
 type Call struct {
 	subCommand string
 	args       []string
+	options    Options
 }
 
 // New returns a new git Call
@@ -59,6 +87,22 @@ func New(s string, a []string) Compatible {
 	}
 }
 
+// Backend is the constructor got commands call git with, it is New
+// unless another backend was chosen when got started.
+var Backend CompatibleConstructor = New
+
+// NewWithOptions returns a constructor of git Calls that use opts,
+// useful to act on a repository other than the current directory one.
+func NewWithOptions(opts Options) CompatibleConstructor {
+	return func(s string, a []string) Compatible {
+		return &Call{
+			subCommand: s,
+			args:       a,
+			options:    opts,
+		}
+	}
+}
+
 // SubCommand returns subcommands
 func (c *Call) SubCommand() string {
 	return c.subCommand
@@ -79,6 +123,16 @@ func (c *Call) SetArgs(a []string) {
 	c.args = a
 }
 
+// Options returns the options git is called with.
+func (c *Call) Options() Options {
+	return c.options
+}
+
+// SetOptions sets the options git is called with.
+func (c *Call) SetOptions(o Options) {
+	c.options = o
+}
+
 // Validate checks that Call has all the required attributes.
 func (c *Call) Validate() error {
 	return nil
@@ -110,7 +164,6 @@ func (c *Call) Git() (ExecCmd, error) {
 func (c *Call) Run() error {
 	cmd, err := c.git(stdCommand)
 	if err != nil {
-		log.Fatal(err)
 		return errors.Trace(err)
 	}
 	return cmd.Run()
@@ -123,9 +176,21 @@ func (c *Call) Checkout(branch string) error {
 	return errors.Annotatef(c.Run(), "cannot checkout %q", branch)
 }
 
+// conflicted returns true if the repository c acts on has paths with
+// unresolved conflicts.
+func (c *Call) conflicted() bool {
+	opts := c.options
+	opts.Stdin = nil
+	out, err := command(opts, []string{"ls-files", "--unmerged"}).Output()
+	return err == nil && len(out) > 0
+}
+
 func (c *Call) git(cmd CommandCraftFunc) (ExecCmd, error) {
+	if c.options.Craft != nil {
+		cmd = c.options.Craft(cmd)
+	}
 	if c.subCommand == "" {
-		return cmd(nil), nil
+		return &errorCmd{cmd: cmd(c.options, nil), call: c}, nil
 	}
-	return cmd(c.asArray()), nil
+	return &errorCmd{cmd: cmd(c.options, c.asArray()), call: c}, nil
 }
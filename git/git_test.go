@@ -1,6 +1,8 @@
 package git
 
 import (
+	"context"
+	"os"
 	"os/exec"
 	"strings"
 	"testing"
@@ -55,7 +57,7 @@ func TestConfigCommandCraftsProperCommandWithArgs(t *testing.T) {
 		subCommand: "blah",
 		args:       []string{"string1", "string2"},
 	}
-	com := command(c.asArray()).(*exec.Cmd)
+	com := command(Options{}, c.asArray()).(*exec.Cmd)
 
 	if !strings.HasSuffix(com.Path, "git") {
 		t.Logf("expected \"<path>/git\" obtained %q", com.Path)
@@ -89,7 +91,7 @@ func TestConfigCommandCraftsProperCommandWithArgs(t *testing.T) {
 }
 
 func TestConfigCommandCraftsProperCommandWithoutArgs(t *testing.T) {
-	com := command(nil).(*exec.Cmd)
+	com := command(Options{}, nil).(*exec.Cmd)
 
 	if !strings.HasSuffix(com.Path, "git") {
 		t.Logf("expected \"<path>/git\" obtained %q", com.Path)
@@ -125,7 +127,7 @@ func (f *fakeCmd) Output() ([]byte, error) {
 func TestGitCallsProrperlyWithConfig(t *testing.T) {
 	var gotStrings []string
 	cmd := fakeCmd{0}
-	cmdFunc := func(s []string) ExecCmd {
+	cmdFunc := func(_ Options, s []string) ExecCmd {
 		gotStrings = s
 		return &cmd
 	}
@@ -177,7 +179,7 @@ func TestGitCallsProrperlyWithConfig(t *testing.T) {
 func TestGitCallsProrperlyWithoutConfig(t *testing.T) {
 	var gotStrings []string
 	cmd := fakeCmd{0}
-	cmdFunc := func(s []string) ExecCmd {
+	cmdFunc := func(_ Options, s []string) ExecCmd {
 		gotStrings = s
 		return &cmd
 	}
@@ -202,3 +204,63 @@ func TestGitCallsProrperlyWithoutConfig(t *testing.T) {
 	}
 
 }
+
+func TestCommandUsesOptions(t *testing.T) {
+	stdin := strings.NewReader("input")
+	opts := Options{
+		Dir:   "/some/repo",
+		Env:   []string{"GIT_AUTHOR_NAME=got"},
+		Stdin: stdin,
+	}
+	com := command(opts, []string{"status"}).(*exec.Cmd)
+	if com.Dir != opts.Dir {
+		t.Logf("expected dir %q got %q", opts.Dir, com.Dir)
+		t.Fail()
+	}
+	if len(com.Env) == 0 || com.Env[len(com.Env)-1] != "GIT_AUTHOR_NAME=got" {
+		t.Logf("expected the extra environment to be added, got %v", com.Env)
+		t.Fail()
+	}
+	if com.Stdin != stdin {
+		t.Logf("expected the given stdin")
+		t.Fail()
+	}
+
+	com = stdCommand(Options{}, []string{"status"}).(*exec.Cmd)
+	if com.Env != nil || com.Dir != "" || com.Stdin != os.Stdin {
+		t.Logf("expected the process environment, got env %v dir %q stdin %v", com.Env, com.Dir, com.Stdin)
+		t.Fail()
+	}
+}
+
+func TestNewWithOptionsPassesOptions(t *testing.T) {
+	var gotOptions Options
+	cmdFunc := func(o Options, _ []string) ExecCmd {
+		gotOptions = o
+		return &fakeCmd{}
+	}
+	c := NewWithOptions(Options{Dir: "/some/repo"})("status", nil).(*Call)
+	if _, err := c.git(cmdFunc); err != nil {
+		t.Fatalf("git failed, got error: %v", err)
+	}
+	if gotOptions.Dir != "/some/repo" {
+		t.Logf("expected dir %q got %q", "/some/repo", gotOptions.Dir)
+		t.Fail()
+	}
+}
+
+func TestCommandContextCancels(t *testing.T) {
+	if _, err := exec.LookPath(CMDGit); err != nil {
+		t.Skip("git is not installed")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := NewWithOptions(Options{Context: ctx})("version", nil)
+	cmd, err := c.Git()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := cmd.Output(); err == nil {
+		t.Fatal("expected a cancelled context to stop git")
+	}
+}
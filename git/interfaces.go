@@ -10,8 +10,9 @@ type ExecCmd interface {
 }
 
 // CommandCraftFunc represents a function that creates an
-// ExecCmd from a command/args string slice.
-type CommandCraftFunc func([]string) ExecCmd
+// ExecCmd from a command/args string slice and the Options
+// git should be called with.
+type CommandCraftFunc func(Options, []string) ExecCmd
 
 // Compatible represents a struct that can perform
 // all the tasks that git should.
@@ -25,6 +26,9 @@ type Compatible interface {
 
 	Args() []string
 	SetArgs([]string)
+
+	Options() Options
+	SetOptions(Options)
 }
 
 // CompatibleConstructor returns a new Git Compatible struct.
@@ -0,0 +1,57 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package git
+
+import "strings"
+
+// logFormat is the git log format for Commit, fields are separated by
+// NUL which cannot be part of a subject.
+const logFormat = "--format=%H%x00%P%x00%s"
+
+// Commit holds the basic information about a commit.
+type Commit struct {
+	Hash    string
+	Parents []string
+	Subject string
+}
+
+// IsMerge returns true if the commit has more than one parent.
+func (c Commit) IsMerge() bool {
+	return len(c.Parents) > 1
+}
+
+// ShortHash returns the abbreviated hash of the commit.
+func (c Commit) ShortHash() string {
+	if len(c.Hash) > 8 {
+		return c.Hash[:8]
+	}
+	return c.Hash
+}
+
+// parseLog parses the output of git log with logFormat.
+func parseLog(out string) []Commit {
+	commits := []Commit{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(line, "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Parents: strings.Fields(fields[1]),
+			Subject: fields[2],
+		})
+	}
+	return commits
+}
+
+// summaryFormat is the git log format for CommitSummary.
+const summaryFormat = "--format=%cr%x00%s"
+
+// CommitSummary holds the subject of a commit and how long ago it was
+// committed in human readable form.
+type CommitSummary struct {
+	Subject string
+	Age     string
+}
@@ -0,0 +1,24 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLog(t *testing.T) {
+	out := "a1\x00b2 c3\x00Merge fix\nb2\x00c3\x00Fix: the\x00thing\n"
+	commits := parseLog(out)
+	expected := []Commit{
+		{Hash: "a1", Parents: []string{"b2", "c3"}, Subject: "Merge fix"},
+		{Hash: "b2", Parents: []string{"c3"}, Subject: "Fix: the\x00thing"},
+	}
+	if !reflect.DeepEqual(commits, expected) {
+		t.Fatalf("expected %#v got %#v", expected, commits)
+	}
+	if !commits[0].IsMerge() || commits[1].IsMerge() {
+		t.Fatal("merge commits misidentified")
+	}
+}
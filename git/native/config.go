@@ -0,0 +1,148 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package native
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// config holds the values of a git config file by key, the section and
+// the name of the keys are lower case, subsections keep their case.
+type config map[string][]string
+
+// get returns the last value of key, as git does.
+func (c config) get(key string) string {
+	values := c[key]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+func (c config) getAll(key string) []string {
+	return c[key]
+}
+
+func (c config) has(key string) bool {
+	return len(c[key]) > 0
+}
+
+func (c config) hasSection(section string) bool {
+	for key := range c {
+		if strings.HasPrefix(key, section+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// readConfig parses the git config file at path, a missing file is an
+// empty config.
+func readConfig(path string) (config, error) {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config{}, nil
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return parseConfig(string(content))
+}
+
+func parseConfig(content string) (config, error) {
+	cfg := config{}
+	section := ""
+	// lines ending in a backslash go on in the next one.
+	content = strings.Replace(content, "\\\r\n", "", -1)
+	content = strings.Replace(content, "\\\n", "", -1)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' {
+			end := strings.LastIndex(line, "]")
+			if end < 0 {
+				return nil, errors.NotValidf("config section %q", line)
+			}
+			section = parseSection(line[1:end])
+			// a key can follow the section in the same line.
+			line = strings.TrimSpace(line[end+1:])
+			if line == "" || line[0] == '#' || line[0] == ';' {
+				continue
+			}
+		}
+		if section == "" {
+			return nil, errors.NotValidf("config key %q outside a section", line)
+		}
+		name, value := line, "true"
+		if eq := strings.Index(line, "="); eq >= 0 {
+			name = strings.TrimSpace(line[:eq])
+			value = parseValue(line[eq+1:])
+		}
+		key := section + "." + strings.ToLower(name)
+		cfg[key] = append(cfg[key], value)
+	}
+	return cfg, nil
+}
+
+// parseSection returns the key prefix for a section header such as
+// `branch "master"` or the deprecated `branch.master`.
+func parseSection(header string) string {
+	header = strings.TrimSpace(header)
+	quote := strings.Index(header, "\"")
+	if quote < 0 {
+		return strings.ToLower(header)
+	}
+	name := strings.ToLower(strings.TrimSpace(header[:quote]))
+	sub := strings.TrimSuffix(header[quote+1:], "\"")
+	sub = strings.Replace(sub, "\\\"", "\"", -1)
+	sub = strings.Replace(sub, "\\\\", "\\", -1)
+	return name + "." + sub
+}
+
+// parseValue returns the value of a config line, dropping comments,
+// quotes and the surrounding spaces and unescaping the rest.
+func parseValue(raw string) string {
+	var value []byte
+	quoted := false
+	// spaces are only kept when something follows them.
+	pending := 0
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+			continue
+		case !quoted && (c == '#' || c == ';'):
+			return string(value)
+		case !quoted && (c == ' ' || c == '\t'):
+			if len(value) > 0 {
+				pending++
+			}
+			continue
+		case c == '\\' && i+1 < len(raw):
+			i++
+			switch raw[i] {
+			case 'n':
+				c = '\n'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			default:
+				c = raw[i]
+			}
+		}
+		for ; pending > 0; pending-- {
+			value = append(value, ' ')
+		}
+		value = append(value, c)
+	}
+	return string(value)
+}
@@ -0,0 +1,209 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+// Package native provides a git backend that answers the read-only
+// queries got makes the most, listing branches, counting the commits
+// ahead and behind and reading the log, by reading refs, packed-refs
+// and objects straight from the repository instead of running git.
+// Every other call, or any query it cannot answer exactly the way git
+// would, is handed to another backend.
+package native
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/perrito666/got/git"
+)
+
+// errNotSupported is returned for the queries that are left to git.
+var errNotSupported = errors.New("not supported natively")
+
+// unsupportedEnv holds the variables that change what repository,
+// objects or config git uses, if any is set git is left to it.
+var unsupportedEnv = []string{
+	"GIT_DIR",
+	"GIT_COMMON_DIR",
+	"GIT_OBJECT_DIRECTORY",
+	"GIT_ALTERNATE_OBJECT_DIRECTORIES",
+	"GIT_NAMESPACE",
+	"GIT_CONFIG",
+	"GIT_CONFIG_PARAMETERS",
+	"GIT_CONFIG_COUNT",
+	"GIT_REPLACE_REF_BASE",
+}
+
+// New returns a constructor of git calls that answers the queries it
+// knows by reading the repository and hands every other call to
+// fallback, which is also used for the options of the calls.
+func New(fallback git.CompatibleConstructor) git.CompatibleConstructor {
+	repos := map[string]*repository{}
+	return func(s string, a []string) git.Compatible {
+		return &call{
+			inner: fallback(s, a),
+			repos: repos,
+		}
+	}
+}
+
+// call is a git.Compatible that wraps the call of another backend.
+type call struct {
+	inner git.Compatible
+	// repos holds the repositories already read by the constructor,
+	// by git directory.
+	repos map[string]*repository
+}
+
+// Git implements git.Compatible.
+func (c *call) Git() (git.ExecCmd, error) {
+	return &cmd{call: c}, nil
+}
+
+// Run implements git.Compatible, the output of Run goes to the user
+// so it is always left to git.
+func (c *call) Run() error {
+	return c.inner.Run()
+}
+
+// Checkout implements git.Compatible.
+func (c *call) Checkout(branch string) error {
+	return c.inner.Checkout(branch)
+}
+
+// SubCommand implements git.Compatible.
+func (c *call) SubCommand() string {
+	return c.inner.SubCommand()
+}
+
+// SetSubCommand implements git.Compatible.
+func (c *call) SetSubCommand(s string) {
+	c.inner.SetSubCommand(s)
+}
+
+// Args implements git.Compatible.
+func (c *call) Args() []string {
+	return c.inner.Args()
+}
+
+// SetArgs implements git.Compatible.
+func (c *call) SetArgs(a []string) {
+	c.inner.SetArgs(a)
+}
+
+// Options implements git.Compatible.
+func (c *call) Options() git.Options {
+	return c.inner.Options()
+}
+
+// SetOptions implements git.Compatible.
+func (c *call) SetOptions(o git.Options) {
+	c.inner.SetOptions(o)
+}
+
+// answer returns what git would output for the call or an error if
+// it cannot be answered natively.
+func (c *call) answer() ([]byte, error) {
+	var query func(*repository) ([]byte, error)
+	args := c.inner.Args()
+	switch c.inner.SubCommand() {
+	case "for-each-ref":
+		q, err := parseForEachRef(args)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		query = q.run
+	case "rev-list":
+		q, err := parseRevList(args)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		query = q.run
+	case "log":
+		q, err := parseLog(args)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		query = q.run
+	default:
+		return nil, errNotSupported
+	}
+	repo, err := c.repository()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// the repository is kept for the next calls but not its open files.
+	defer repo.objects.close()
+	return query(repo)
+}
+
+// repository returns the repository the call acts on.
+func (c *call) repository() (*repository, error) {
+	opts := c.inner.Options()
+	if opts.Context != nil && opts.Context.Err() != nil {
+		return nil, errors.Trace(opts.Context.Err())
+	}
+	for _, name := range unsupportedEnv {
+		if os.Getenv(name) != "" {
+			return nil, errNotSupported
+		}
+		for _, env := range opts.Env {
+			if strings.HasPrefix(env, name+"=") {
+				return nil, errNotSupported
+			}
+		}
+	}
+	dir := opts.Dir
+	if dir == "" {
+		var err error
+		if dir, err = os.Getwd(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	gitDir, err := findGitDir(dir)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if repo, ok := c.repos[gitDir]; ok {
+		return repo, nil
+	}
+	repo, err := openRepository(gitDir)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	c.repos[gitDir] = repo
+	return repo, nil
+}
+
+// cmd is the git.ExecCmd of a call.
+type cmd struct {
+	call *call
+}
+
+// Run implements git.ExecCmd.
+func (c *cmd) Run() error {
+	inner, err := c.call.inner.Git()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return inner.Run()
+}
+
+// Output implements git.ExecCmd, git is called if the query could not
+// be answered, which also reports any problem with the repository the
+// way got expects.
+func (c *cmd) Output() ([]byte, error) {
+	if out, err := c.call.answer(); err == nil {
+		return out, nil
+	}
+	inner, err := c.call.inner.Git()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return inner.Output()
+}
@@ -0,0 +1,265 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package native
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/perrito666/got/git"
+)
+
+const (
+	refFormat = "--format=%(refname)%00%(upstream)%00%(HEAD)%00%(objectname)%00%(committerdate:unix)%00%(symref)"
+	logFormat = "--format=%H%x00%P%x00%s"
+)
+
+// queries are answered natively, they are what got asks git the most.
+var queries = [][]string{
+	{"for-each-ref", refFormat, "refs/heads"},
+	{"for-each-ref", refFormat, "refs/remotes"},
+	{"for-each-ref", refFormat},
+	{"rev-list", "--left-right", "--count", "master...1.2"},
+	{"rev-list", "--left-right", "--count", "1.2...fix_1.2_1"},
+	{"rev-list", "--left-right", "--count", "feature_master_x...1.2"},
+	{"rev-list", "--left-right", "--count", "origin/master...master"},
+	{"rev-list", "--left-right", "--count", "master...master"},
+	{"rev-list", "--left-right", "--count", "fix_1.2_1...feature_master_x"},
+	{"log", logFormat},
+	{"log", logFormat, "master"},
+	{"log", logFormat, "--reverse", "--no-merges", "master..fix_1.2_1"},
+	{"log", logFormat, "--first-parent", "1.2"},
+	{"log", logFormat, "1.2", "^master"},
+	{"log", logFormat, "--reverse", "-2", "1.2", "feature_master_x"},
+	{"log", logFormat, "v1"},
+	{"log", logFormat, "heads/1.2", "origin/fix_1.2_1..refs/heads/feature_master_x"},
+}
+
+// skewedQueries walk the history of addSkewed.
+var skewedQueries = [][]string{
+	{"rev-list", "--left-right", "--count", "skew_left...skew_right"},
+	{"rev-list", "--left-right", "--count", "skew_right...skew_left"},
+	{"log", logFormat, "skew_left", "^skew_right"},
+	{"log", logFormat, "skew_right", "^skew_left"},
+}
+
+// countingCall counts the calls that reach git.
+type countingCall struct {
+	git.Compatible
+	calls *int
+}
+
+func (c countingCall) Git() (git.ExecCmd, error) {
+	*c.calls++
+	return c.Compatible.Git()
+}
+
+// testRepo is a repository built with git.
+type testRepo struct {
+	t    *testing.T
+	opts git.Options
+	// date is the committer date of the next commit.
+	date int
+}
+
+func (r *testRepo) git(args ...string) string {
+	opts := r.opts
+	date := fmt.Sprintf("%d +0000", r.date)
+	opts.Env = append(opts.Env, "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+	cmd, err := git.NewWithOptions(opts)(args[0], args[1:]).Git()
+	if err != nil {
+		r.t.Fatalf("cannot call git %q: %v", args, err)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		r.t.Fatalf("git %q failed: %v", args, err)
+	}
+	return string(out)
+}
+
+// commit commits a change to a file with the given message, dated
+// seconds after the previous one.
+func (r *testRepo) commit(message string, seconds int) {
+	r.date += seconds
+	path := filepath.Join(r.opts.Dir, "file")
+	content, _ := ioutil.ReadFile(path)
+	content = append(content, message+"\n"...)
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		r.t.Fatal(err)
+	}
+	r.git("add", "file")
+	r.git("commit", "-q", "-m", message)
+}
+
+func newTestRepo(t *testing.T, dir string) *testRepo {
+	r := &testRepo{t: t, date: 1500000000, opts: git.Options{
+		Dir: filepath.Join(dir, "work"),
+		Env: []string{
+			"HOME=" + dir,
+			"GIT_CONFIG_NOSYSTEM=1",
+			"GIT_AUTHOR_NAME=got", "GIT_AUTHOR_EMAIL=got@example.com",
+			"GIT_COMMITTER_NAME=got", "GIT_COMMITTER_EMAIL=got@example.com",
+		},
+	}}
+	if err := os.Mkdir(r.opts.Dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	r.git("init", "-q")
+	r.git("symbolic-ref", "HEAD", "refs/heads/master")
+	r.commit("Initial commit", 0)
+	r.commit("Second commit\nwrapped  \n\nwith a body", 100)
+	r.git("checkout", "-q", "-b", "1.2")
+	r.commit("Release 1.2", 100)
+	r.git("checkout", "-q", "-b", "fix_1.2_1")
+	r.commit("Fix the crash", 100)
+	// commits with the same date are walked in the order they are found.
+	r.commit("Fix the other crash", 0)
+	r.git("checkout", "-q", "master")
+	r.commit("Keep going", 50)
+	r.git("checkout", "-q", "1.2")
+	r.date += 50
+	r.git("merge", "-q", "--no-ff", "-m", "Merge fix_1.2_1", "fix_1.2_1")
+	r.git("checkout", "-q", "-b", "feature_master_x", "master")
+	r.commit("Start x", 100)
+	r.commit("Finish x", 0)
+	r.git("tag", "-a", "-m", "Version 1", "v1", "master")
+
+	remote := filepath.Join(dir, "remote.git")
+	r.git("init", "-q", "--bare", remote)
+	r.git("remote", "add", "origin", remote)
+	r.git("push", "-q", "origin", "master", "1.2", "fix_1.2_1")
+	r.git("remote", "set-head", "origin", "master")
+	r.git("branch", "-q", "--set-upstream-to=origin/master", "master")
+	r.git("branch", "-q", "--set-upstream-to=origin/fix_1.2_1", "fix_1.2_1")
+	r.git("branch", "-q", "--set-upstream-to=master", "feature_master_x")
+	return r
+}
+
+// addSkewed adds skew_left and skew_right, they share a commit dated
+// long before its parent, as if made on a machine with a wrong clock,
+// which is reached after all the rest of the history from master.
+func (r *testRepo) addSkewed() {
+	r.git("checkout", "-q", "-b", "skew_left", "master")
+	r.commit("Before the skew", 100)
+	r.git("checkout", "-q", "-b", "skew_right")
+	now := r.date
+	r.date = 1000000000
+	r.commit("Skewed", 0)
+	r.date = now
+	r.commit("After the skew", 100)
+	r.git("checkout", "-q", "skew_left")
+	r.git("merge", "-q", "--no-ff", "-m", "Merge the skew", "skew_right~1")
+}
+
+func output(t *testing.T, newGit git.CompatibleConstructor, query []string) string {
+	cmd, err := newGit(query[0], query[1:]).Git()
+	if err != nil {
+		t.Fatalf("cannot call git %q: %v", query, err)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git %q failed: %v", query, err)
+	}
+	return string(out)
+}
+
+func TestNativeMatchesGit(t *testing.T) {
+	if _, err := exec.LookPath(git.CMDGit); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "got")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	r := newTestRepo(t, dir)
+
+	calls := 0
+	exe := git.NewWithOptions(r.opts)
+	native := New(func(s string, a []string) git.Compatible {
+		return countingCall{Compatible: exe(s, a), calls: &calls}
+	})
+	compare := func(state string, queries [][]string) {
+		for _, query := range queries {
+			calls = 0
+			expected := output(t, exe, query)
+			got := output(t, native, query)
+			if got != expected {
+				t.Logf("%s: expected %q for %q got %q", state, expected, query, got)
+				t.Fail()
+			}
+			if calls != 0 {
+				t.Logf("%s: expected %q to be answered natively", state, query)
+				t.Fail()
+			}
+		}
+	}
+
+	compare("loose objects", queries)
+	r.git("pack-refs", "--all")
+	r.git("repack", "-q", "-a", "-d", "-f", "--depth=10")
+	compare("packed objects", queries)
+	// new commits are loose, as are the refs pointing to them.
+	r.git("checkout", "-q", "fix_1.2_1")
+	r.commit("Fix it again", 100)
+	r.git("checkout", "-q", "--detach", "master")
+	compare("packed and loose objects", queries)
+	r.addSkewed()
+	compare("skewed dates", skewedQueries)
+	// the packs read so far are replaced by a new one.
+	r.git("repack", "-q", "-a", "-d")
+	compare("repacked", queries)
+
+	query := native(queries[len(queries)-1][0], queries[len(queries)-1][1:]).(*call)
+	if _, err := query.answer(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, repo := range query.repos {
+		for _, p := range repo.objects.packs {
+			if p.file != nil {
+				t.Fatalf("expected %q to be closed after answering", p.path)
+			}
+		}
+	}
+}
+
+func TestNativeFallsBack(t *testing.T) {
+	if _, err := exec.LookPath(git.CMDGit); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "got")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	r := newTestRepo(t, dir)
+
+	calls := 0
+	exe := git.NewWithOptions(r.opts)
+	native := New(func(s string, a []string) git.Compatible {
+		return countingCall{Compatible: exe(s, a), calls: &calls}
+	})
+	for _, query := range [][]string{
+		{"log", "--format=%an %cr", "master"},
+		{"log", logFormat, "master~1"},
+		{"log", logFormat, "1.2...master"},
+		{"rev-parse", "--abbrev-ref", "HEAD"},
+		{"for-each-ref", "--format=%(refname:short)", "refs/heads"},
+	} {
+		calls = 0
+		expected := output(t, exe, query)
+		if got := output(t, native, query); got != expected || calls != 1 {
+			t.Logf("expected %q from git for %q got %q after %d calls", expected, query, got, calls)
+			t.Fail()
+		}
+	}
+	if !strings.Contains(output(t, native, queries[0]), "refs/heads/master") {
+		t.Fatal("expected the native backend to list master")
+	}
+}
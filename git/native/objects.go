@@ -0,0 +1,524 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package native
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// objectStore reads loose and packed objects from an objects directory
+// and its alternates.
+type objectStore struct {
+	dirs  []string
+	packs []*pack
+}
+
+func newObjectStore(dir string) (*objectStore, error) {
+	s := &objectStore{dirs: []string{dir}}
+	if err := s.readAlternates(dir); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := s.loadPacks(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return s, nil
+}
+
+func (s *objectStore) readAlternates(dir string) error {
+	content, err := ioutil.ReadFile(filepath.Join(dir, "info", "alternates"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(dir, line)
+		}
+		s.dirs = append(s.dirs, filepath.Clean(line))
+	}
+	return nil
+}
+
+// loadPacks reads the indexes of the packs not known yet and forgets
+// the ones that are gone, git might have repacked since the store was
+// created.
+func (s *objectStore) loadPacks() error {
+	loaded := map[string]bool{}
+	packs := s.packs[:0]
+	for _, p := range s.packs {
+		if _, err := os.Stat(p.path); err != nil {
+			p.close()
+			continue
+		}
+		loaded[p.path] = true
+		packs = append(packs, p)
+	}
+	s.packs = packs
+	for _, dir := range s.dirs {
+		indexes, err := filepath.Glob(filepath.Join(dir, "pack", "*.idx"))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, index := range indexes {
+			path := strings.TrimSuffix(index, ".idx") + ".pack"
+			if loaded[path] {
+				continue
+			}
+			p, err := openPack(path)
+			if err != nil {
+				return errors.Annotatef(err, "cannot open pack %q", path)
+			}
+			s.packs = append(s.packs, p)
+		}
+	}
+	return nil
+}
+
+// read returns the type and content of the object with the given hash.
+func (s *objectStore) read(hash string) (string, []byte, error) {
+	id, err := hex.DecodeString(hash)
+	if err != nil || len(id) != 20 {
+		return "", nil, errors.NotValidf("object name %q", hash)
+	}
+	for retry := 0; retry < 2; retry++ {
+		for _, p := range s.packs {
+			if offset, ok := p.find(id); ok {
+				kind, data, err := p.read(offset, s)
+				// the pack was removed, a newer one must have the object.
+				if os.IsNotExist(errors.Cause(err)) {
+					break
+				}
+				return kind, data, errors.Trace(err)
+			}
+		}
+		for _, dir := range s.dirs {
+			kind, data, err := readLooseObject(filepath.Join(dir, hash[:2], hash[2:]))
+			if os.IsNotExist(errors.Cause(err)) {
+				continue
+			}
+			return kind, data, errors.Annotatef(err, "cannot read object %s", hash)
+		}
+		// git might have packed the object after we last looked.
+		if err := s.loadPacks(); err != nil {
+			return "", nil, errors.Trace(err)
+		}
+	}
+	return "", nil, errors.NotFoundf("object %s", hash)
+}
+
+// close closes the pack files, they are opened again when needed.
+func (s *objectStore) close() {
+	for _, p := range s.packs {
+		p.close()
+	}
+}
+
+func readLooseObject(path string) (string, []byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	defer f.Close()
+	z, err := zlib.NewReader(f)
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	defer z.Close()
+	content, err := ioutil.ReadAll(z)
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	nul := bytes.IndexByte(content, 0)
+	if nul < 0 {
+		return "", nil, errors.NotValidf("object header")
+	}
+	fields := strings.Fields(string(content[:nul]))
+	if len(fields) != 2 {
+		return "", nil, errors.NotValidf("object header %q", content[:nul])
+	}
+	data := content[nul+1:]
+	if size, err := strconv.Atoi(fields[1]); err != nil || size != len(data) {
+		return "", nil, errors.NotValidf("object size %q", fields[1])
+	}
+	return fields[0], data, nil
+}
+
+// pack types of the objects in a pack file.
+const (
+	packCommit   = 1
+	packTree     = 2
+	packBlob     = 3
+	packTag      = 4
+	packOfsDelta = 6
+	packRefDelta = 7
+)
+
+var packKinds = map[byte]string{
+	packCommit: "commit",
+	packTree:   "tree",
+	packBlob:   "blob",
+	packTag:    "tag",
+}
+
+// pack reads the objects of a pack file using its index.
+type pack struct {
+	path string
+	// file is only open while objects are read from the pack.
+	file   *os.File
+	fanout [256]uint32
+	// ids holds the sorted object names, 20 bytes each.
+	ids     []byte
+	offsets []int64
+	// bases holds the objects already read as the base of a delta.
+	bases map[int64]packedObject
+}
+
+type packedObject struct {
+	kind string
+	data []byte
+}
+
+// packIndexMagic starts the version 2 indexes, version 1 ones start
+// with the fanout table.
+var packIndexMagic = []byte{0xff, 't', 'O', 'c'}
+
+func openPack(path string) (*pack, error) {
+	index, err := ioutil.ReadFile(strings.TrimSuffix(path, ".pack") + ".idx")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	p := &pack{path: path, bases: map[int64]packedObject{}}
+	if err := p.parseIndex(index); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return p, nil
+}
+
+func (p *pack) open() (*os.File, error) {
+	if p.file == nil {
+		file, err := os.Open(p.path)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		p.file = file
+	}
+	return p.file, nil
+}
+
+func (p *pack) close() {
+	if p.file != nil {
+		p.file.Close()
+		p.file = nil
+	}
+}
+
+func (p *pack) parseIndex(index []byte) error {
+	version2 := bytes.HasPrefix(index, packIndexMagic)
+	fanoutAt := 0
+	if version2 {
+		if len(index) < 8 || binary.BigEndian.Uint32(index[4:]) != 2 {
+			return errors.NotSupportedf("pack index version")
+		}
+		fanoutAt = 8
+	}
+	if len(index) < fanoutAt+256*4 {
+		return errors.NotValidf("pack index")
+	}
+	for i := range p.fanout {
+		p.fanout[i] = binary.BigEndian.Uint32(index[fanoutAt+i*4:])
+	}
+	count := int(p.fanout[255])
+	entries := index[fanoutAt+256*4:]
+	p.offsets = make([]int64, count)
+	if !version2 {
+		// each entry is a 4 byte offset followed by the name.
+		if len(entries) < count*24 {
+			return errors.NotValidf("pack index")
+		}
+		p.ids = make([]byte, 0, count*20)
+		for i := 0; i < count; i++ {
+			entry := entries[i*24:]
+			p.offsets[i] = int64(binary.BigEndian.Uint32(entry))
+			p.ids = append(p.ids, entry[4:24]...)
+		}
+		return nil
+	}
+	// names, crc32s, offsets and the large offsets table.
+	if len(entries) < count*28 {
+		return errors.NotValidf("pack index")
+	}
+	p.ids = entries[:count*20]
+	offsets := entries[count*24:]
+	large := offsets[count*4:]
+	for i := 0; i < count; i++ {
+		offset := binary.BigEndian.Uint32(offsets[i*4:])
+		if offset&0x80000000 == 0 {
+			p.offsets[i] = int64(offset)
+			continue
+		}
+		at := int(offset&0x7fffffff) * 8
+		if len(large) < at+8 {
+			return errors.NotValidf("pack index large offset")
+		}
+		p.offsets[i] = int64(binary.BigEndian.Uint64(large[at:]))
+	}
+	return nil
+}
+
+// find returns the offset in the pack of the object with the given id.
+func (p *pack) find(id []byte) (int64, bool) {
+	lo := 0
+	if id[0] > 0 {
+		lo = int(p.fanout[id[0]-1])
+	}
+	hi := int(p.fanout[id[0]])
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		return bytes.Compare(p.ids[(lo+i)*20:(lo+i+1)*20], id) >= 0
+	})
+	if i < hi && bytes.Equal(p.ids[i*20:(i+1)*20], id) {
+		return p.offsets[i], true
+	}
+	return 0, false
+}
+
+// read returns the type and content of the object at offset, s reads
+// the bases of deltas given by name.
+func (p *pack) read(offset int64, s *objectStore) (string, []byte, error) {
+	file, err := p.open()
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	r := bufio.NewReader(io.NewSectionReader(file, offset, 1<<62))
+	c, err := r.ReadByte()
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	kind := (c >> 4) & 7
+	size := int64(c & 0x0f)
+	for shift := uint(4); c&0x80 != 0; shift += 7 {
+		if c, err = r.ReadByte(); err != nil {
+			return "", nil, errors.Trace(err)
+		}
+		size |= int64(c&0x7f) << shift
+	}
+
+	var base packedObject
+	switch kind {
+	case packOfsDelta:
+		if c, err = r.ReadByte(); err != nil {
+			return "", nil, errors.Trace(err)
+		}
+		distance := int64(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = r.ReadByte(); err != nil {
+				return "", nil, errors.Trace(err)
+			}
+			distance = (distance+1)<<7 | int64(c&0x7f)
+		}
+		if base, err = p.base(offset-distance, s); err != nil {
+			return "", nil, errors.Trace(err)
+		}
+	case packRefDelta:
+		id := make([]byte, 20)
+		if _, err := io.ReadFull(r, id); err != nil {
+			return "", nil, errors.Trace(err)
+		}
+		if base.kind, base.data, err = s.read(hex.EncodeToString(id)); err != nil {
+			return "", nil, errors.Trace(err)
+		}
+	default:
+		if _, ok := packKinds[kind]; !ok {
+			return "", nil, errors.NotValidf("pack object type %d", kind)
+		}
+	}
+
+	z, err := zlib.NewReader(r)
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	defer z.Close()
+	data := make([]byte, size)
+	if _, err := io.ReadFull(z, data); err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	if base.kind == "" {
+		return packKinds[kind], data, nil
+	}
+	patched, err := applyDelta(base.data, data)
+	return base.kind, patched, errors.Trace(err)
+}
+
+// base returns the object at offset, which is the base of a delta,
+// objects are often the base of many deltas so they are kept.
+func (p *pack) base(offset int64, s *objectStore) (packedObject, error) {
+	if base, ok := p.bases[offset]; ok {
+		return base, nil
+	}
+	kind, data, err := p.read(offset, s)
+	if err != nil {
+		return packedObject{}, errors.Trace(err)
+	}
+	base := packedObject{kind: kind, data: data}
+	// trees and blobs are not needed more than once.
+	if kind == "commit" || kind == "tag" {
+		p.bases[offset] = base
+	}
+	return base, nil
+}
+
+// applyDelta returns the object the delta produces from base.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	baseSize, delta := deltaSize(delta)
+	if baseSize != len(base) {
+		return nil, errors.NotValidf("delta base size")
+	}
+	size, delta := deltaSize(delta)
+	result := make([]byte, 0, size)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+		if op&0x80 == 0 {
+			if op == 0 || int(op) > len(delta) {
+				return nil, errors.NotValidf("delta instruction")
+			}
+			result = append(result, delta[:op]...)
+			delta = delta[op:]
+			continue
+		}
+		// the bits of op tell which bytes of the offset and size of the
+		// copy follow.
+		var offset, length int
+		for i := uint(0); i < 7; i++ {
+			if op&(1<<i) == 0 {
+				continue
+			}
+			if len(delta) == 0 {
+				return nil, errors.NotValidf("delta copy")
+			}
+			if i < 4 {
+				offset |= int(delta[0]) << (8 * i)
+			} else {
+				length |= int(delta[0]) << (8 * (i - 4))
+			}
+			delta = delta[1:]
+		}
+		if length == 0 {
+			length = 0x10000
+		}
+		if offset+length > len(base) {
+			return nil, errors.NotValidf("delta copy")
+		}
+		result = append(result, base[offset:offset+length]...)
+	}
+	if len(result) != size {
+		return nil, errors.NotValidf("delta result size")
+	}
+	return result, nil
+}
+
+// deltaSize reads one of the sizes at the start of a delta.
+func deltaSize(delta []byte) (int, []byte) {
+	size := 0
+	for shift := uint(0); len(delta) > 0; shift += 7 {
+		c := delta[0]
+		delta = delta[1:]
+		size |= int(c&0x7f) << shift
+		if c&0x80 == 0 {
+			break
+		}
+	}
+	return size, delta
+}
+
+// commit holds what got needs from a commit object.
+type commit struct {
+	hash    string
+	parents []string
+	// date is the committer date, which git orders commits by.
+	date    int64
+	subject string
+}
+
+func parseCommit(hash string, data []byte) (*commit, error) {
+	c := &commit{hash: hash}
+	end := bytes.Index(data, []byte("\n\n"))
+	if end < 0 {
+		end = len(data)
+	}
+	for _, line := range strings.Split(string(data[:end]), "\n") {
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "parent":
+			c.parents = append(c.parents, fields[1])
+		case "committer":
+			// the date follows the identity as "<seconds> <zone>".
+			parts := strings.Fields(fields[1][strings.LastIndex(fields[1], ">")+1:])
+			if len(parts) > 0 {
+				c.date, _ = strconv.ParseInt(parts[0], 10, 64)
+			}
+		case "encoding":
+			// git log would re-encode the message.
+			if encoding := strings.ToLower(fields[1]); encoding != "utf-8" && encoding != "utf8" {
+				return nil, errNotSupported
+			}
+		}
+	}
+	if end < len(data) {
+		c.subject = subject(string(data[end+2:]))
+	}
+	return c, nil
+}
+
+// subject returns the first paragraph of message in one line, as %s
+// of git log does.
+func subject(message string) string {
+	lines := []string{}
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimRight(line, " \t\r\f\v")
+		if line == "" {
+			if len(lines) == 0 {
+				continue
+			}
+			break
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, " ")
+}
+
+// header returns the value of the first header with the given name in
+// a commit or tag object.
+func header(data []byte, name string) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, name+" ") {
+			return strings.TrimPrefix(line, name+" ")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,376 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package native
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// forEachRef is a for-each-ref query.
+type forEachRef struct {
+	format   []formatPart
+	patterns []string
+}
+
+// formatPart is either a literal or an atom of a format.
+type formatPart struct {
+	literal string
+	atom    string
+}
+
+var refAtoms = map[string]bool{
+	"refname":            true,
+	"upstream":           true,
+	"HEAD":               true,
+	"objectname":         true,
+	"committerdate:unix": true,
+	"symref":             true,
+}
+
+// parseForEachRef parses the arguments of a for-each-ref query, only
+// a format of the atoms in refAtoms and plain patterns are known.
+func parseForEachRef(args []string) (*forEachRef, error) {
+	q := &forEachRef{}
+	format := "%(objectname) %(objecttype)\t%(refname)"
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--format="):
+			format = strings.TrimPrefix(arg, "--format=")
+		case strings.HasPrefix(arg, "-") || strings.ContainsAny(arg, "*?["):
+			return nil, errNotSupported
+		default:
+			q.patterns = append(q.patterns, strings.TrimSuffix(arg, "/"))
+		}
+	}
+	var literal []byte
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			literal = append(literal, format[i])
+			continue
+		}
+		rest := format[i+1:]
+		switch {
+		case strings.HasPrefix(rest, "%"):
+			literal = append(literal, '%')
+			i++
+		case strings.HasPrefix(rest, "("):
+			end := strings.Index(rest, ")")
+			if end < 0 || !refAtoms[rest[1:end]] {
+				return nil, errNotSupported
+			}
+			q.format = append(q.format, formatPart{literal: string(literal)}, formatPart{atom: rest[1:end]})
+			literal = nil
+			i += end + 1
+		default:
+			// %xx is the byte with hex code xx.
+			b, err := hex.DecodeString(rest[:min(2, len(rest))])
+			if err != nil || len(b) != 1 {
+				return nil, errNotSupported
+			}
+			literal = append(literal, b[0])
+			i += 2
+		}
+	}
+	q.format = append(q.format, formatPart{literal: string(literal)})
+	return q, nil
+}
+
+func (q *forEachRef) matches(name string) bool {
+	if len(q.patterns) == 0 {
+		return true
+	}
+	for _, pattern := range q.patterns {
+		if name == pattern || strings.HasPrefix(name, pattern+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *forEachRef) run(r *repository) ([]byte, error) {
+	cfg, err := r.config()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	refs, err := r.refs()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	head, _, err := r.head(refs)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, errors.Trace(err)
+	}
+	var out bytes.Buffer
+	for _, ref := range refs {
+		if !q.matches(ref.name) {
+			continue
+		}
+		hash, err := resolveRef(refs, ref.name)
+		if errors.IsNotFound(err) {
+			// git ignores broken symbolic refs.
+			continue
+		}
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, part := range q.format {
+			switch part.atom {
+			case "":
+				out.WriteString(part.literal)
+			case "refname":
+				out.WriteString(ref.name)
+			case "upstream":
+				if strings.HasPrefix(ref.name, "refs/heads/") {
+					out.WriteString(upstream(cfg, ref.name))
+				}
+			case "HEAD":
+				if ref.name == head {
+					out.WriteString("*")
+				} else {
+					out.WriteString(" ")
+				}
+			case "objectname":
+				out.WriteString(hash)
+			case "committerdate:unix":
+				c, err := r.commit(hash)
+				// only commits have a committer.
+				if errors.IsNotValid(err) {
+					break
+				}
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+				out.WriteString(strconv.FormatInt(c.date, 10))
+			case "symref":
+				out.WriteString(ref.target)
+			}
+		}
+		out.WriteString("\n")
+	}
+	return out.Bytes(), nil
+}
+
+// aheadBehind is a rev-list --left-right --count query of a symmetric
+// range, such as master...fix_master_1.
+type aheadBehind struct {
+	left, right string
+}
+
+func parseRevList(args []string) (*aheadBehind, error) {
+	q := &aheadBehind{}
+	leftRight, count := false, false
+	for _, arg := range args {
+		switch {
+		case arg == "--left-right":
+			leftRight = true
+		case arg == "--count":
+			count = true
+		case strings.HasPrefix(arg, "-") || q.left != "" || strings.Count(arg, "...") != 1:
+			return nil, errNotSupported
+		default:
+			parts := strings.Split(arg, "...")
+			q.left, q.right = orHead(parts[0]), orHead(parts[1])
+		}
+	}
+	if !leftRight || !count || q.left == "" {
+		return nil, errNotSupported
+	}
+	return q, nil
+}
+
+func (q *aheadBehind) run(r *repository) ([]byte, error) {
+	refs, err := r.refs()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	left, err := r.resolve(refs, q.left)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	right, err := r.resolve(refs, q.right)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	leftOnly, rightOnly, err := r.symmetricDifference(left, right)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return []byte(fmt.Sprintf("%d\t%d\n", leftOnly, rightOnly)), nil
+}
+
+// logQuery is a git log query.
+type logQuery struct {
+	format      string
+	revs        []string
+	reverse     bool
+	noMerges    bool
+	firstParent bool
+	// maxCount is the most commits to show, negative for no limit.
+	maxCount int
+}
+
+// parseLog parses the arguments of a log query, only a format of %H,
+// %P, %s, %n and %x escapes, ranges of plain revisions and a few flags
+// are known.
+func parseLog(args []string) (*logQuery, error) {
+	q := &logQuery{maxCount: -1}
+	for i, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--format="):
+			q.format = strings.TrimPrefix(strings.TrimPrefix(arg, "--format="), "tformat:")
+		case arg == "--reverse":
+			q.reverse = true
+		case arg == "--no-merges":
+			q.noMerges = true
+		case arg == "--first-parent":
+			q.firstParent = true
+		case strings.HasPrefix(arg, "--max-count="):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "--max-count="))
+			if err != nil {
+				return nil, errNotSupported
+			}
+			q.maxCount = n
+		case arg == "--":
+			// paths would filter the commits.
+			if i != len(args)-1 {
+				return nil, errNotSupported
+			}
+		case strings.HasPrefix(arg, "-"):
+			n, err := strconv.Atoi(arg[1:])
+			if err != nil {
+				return nil, errNotSupported
+			}
+			q.maxCount = n
+		default:
+			q.revs = append(q.revs, arg)
+		}
+	}
+	// without % the format is the name of a predefined one.
+	if !strings.Contains(q.format, "%") || strings.HasPrefix(q.format, "format:") {
+		return nil, errNotSupported
+	}
+	for i := 0; i < len(q.format); i++ {
+		if q.format[i] != '%' {
+			continue
+		}
+		rest := q.format[i+1:]
+		switch {
+		case strings.HasPrefix(rest, "x"):
+			if _, err := hex.DecodeString(rest[1:min(3, len(rest))]); err != nil || len(rest) < 3 {
+				return nil, errNotSupported
+			}
+			i += 3
+		case rest != "" && strings.ContainsRune("HPsn%", rune(rest[0])):
+			i++
+		default:
+			return nil, errNotSupported
+		}
+	}
+	if len(q.revs) == 0 {
+		q.revs = []string{"HEAD"}
+	}
+	return q, nil
+}
+
+func (q *logQuery) run(r *repository) ([]byte, error) {
+	refs, err := r.refs()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	starts := []start{}
+	for _, rev := range q.revs {
+		if strings.Contains(rev, "...") {
+			return nil, errNotSupported
+		}
+		// a..b is ^a b.
+		if parts := strings.SplitN(rev, "..", 2); len(parts) == 2 {
+			from, err := r.resolve(refs, orHead(parts[0]))
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			starts = append(starts, start{hash: from, uninteresting: true})
+			rev = orHead(parts[1])
+		}
+		uninteresting := strings.HasPrefix(rev, "^")
+		hash, err := r.resolve(refs, strings.TrimPrefix(rev, "^"))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		starts = append(starts, start{hash: hash, uninteresting: uninteresting})
+	}
+	commits, err := r.walk(starts, q.firstParent)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	shown := []*commit{}
+	for _, c := range commits {
+		if q.noMerges && len(c.parents) > 1 {
+			continue
+		}
+		if q.maxCount >= 0 && len(shown) == q.maxCount {
+			break
+		}
+		shown = append(shown, c)
+	}
+	if q.reverse {
+		for i, j := 0, len(shown)-1; i < j; i, j = i+1, j-1 {
+			shown[i], shown[j] = shown[j], shown[i]
+		}
+	}
+	var out bytes.Buffer
+	for _, c := range shown {
+		q.write(&out, c)
+	}
+	return out.Bytes(), nil
+}
+
+// write writes the commit in the format of the query, which always
+// ends in a new line as with tformat.
+func (q *logQuery) write(out *bytes.Buffer, c *commit) {
+	for i := 0; i < len(q.format); i++ {
+		if q.format[i] != '%' {
+			out.WriteByte(q.format[i])
+			continue
+		}
+		i++
+		switch q.format[i] {
+		case 'H':
+			out.WriteString(c.hash)
+		case 'P':
+			out.WriteString(strings.Join(c.parents, " "))
+		case 's':
+			out.WriteString(c.subject)
+		case 'n':
+			out.WriteByte('\n')
+		case '%':
+			out.WriteByte('%')
+		case 'x':
+			b, _ := hex.DecodeString(q.format[i+1 : i+3])
+			out.Write(b)
+			i += 2
+		}
+	}
+	out.WriteByte('\n')
+}
+
+// orHead returns rev or HEAD if it is empty, as the sides of a range.
+func orHead(rev string) string {
+	if rev == "" {
+		return "HEAD"
+	}
+	return rev
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,382 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package native
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// repository reads the refs and objects of a git repository.
+type repository struct {
+	// gitDir holds HEAD, it is the directory of the worktree in linked
+	// worktrees.
+	gitDir string
+	// commonDir holds the refs, objects and config shared by worktrees.
+	commonDir string
+	objects   *objectStore
+	shallow   map[string]bool
+	commits   map[string]*commit
+}
+
+// findGitDir returns the git directory of the repository dir is in,
+// the same way git does when GIT_DIR is not set.
+func findGitDir(dir string) (string, error) {
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			if info.IsDir() {
+				return dotGit, nil
+			}
+			// worktrees and submodules point to the git directory.
+			content, err := ioutil.ReadFile(dotGit)
+			if err != nil {
+				return "", errors.Trace(err)
+			}
+			target := strings.TrimSpace(string(content))
+			if !strings.HasPrefix(target, "gitdir: ") {
+				return "", errors.NotValidf("git file %q", dotGit)
+			}
+			target = strings.TrimPrefix(target, "gitdir: ")
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(dir, target)
+			}
+			return filepath.Clean(target), nil
+		}
+		if isGitDir(dir) {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.NotFoundf("git repository")
+		}
+		dir = parent
+	}
+}
+
+// isGitDir returns true if dir looks like a bare repository.
+func isGitDir(dir string) bool {
+	for _, name := range []string{"HEAD", "objects", "refs"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func openRepository(gitDir string) (*repository, error) {
+	commonDir := gitDir
+	if content, err := ioutil.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir = strings.TrimSpace(string(content))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+		commonDir = filepath.Clean(commonDir)
+	}
+	objects, err := newObjectStore(filepath.Join(commonDir, "objects"))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	shallow := map[string]bool{}
+	if content, err := ioutil.ReadFile(filepath.Join(commonDir, "shallow")); err == nil {
+		for _, hash := range strings.Fields(string(content)) {
+			shallow[hash] = true
+		}
+	}
+	return &repository{
+		gitDir:    gitDir,
+		commonDir: commonDir,
+		objects:   objects,
+		shallow:   shallow,
+		commits:   map[string]*commit{},
+	}, nil
+}
+
+// config reads the repository configuration and checks that nothing
+// in the repository changes how refs and objects are read.
+func (r *repository) config() (config, error) {
+	cfg, err := readConfig(filepath.Join(r.commonDir, "config"))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if cfg.has("include.path") || cfg.hasSection("includeif") {
+		return nil, errNotSupported
+	}
+	if storage := cfg.get("extensions.refstorage"); storage != "" && storage != "files" {
+		return nil, errNotSupported
+	}
+	if format := cfg.get("extensions.objectformat"); format != "" && format != "sha1" {
+		return nil, errNotSupported
+	}
+	if _, err := os.Stat(filepath.Join(r.commonDir, "info", "grafts")); err == nil {
+		return nil, errNotSupported
+	}
+	return cfg, nil
+}
+
+// ref is a ref as stored in the repository.
+type ref struct {
+	name string
+	// hash is what the ref points to, empty for symbolic refs.
+	hash string
+	// target is the ref a symbolic ref points to.
+	target string
+}
+
+// refs returns all the refs under refs/, loose ones override packed
+// ones, sorted by name.
+func (r *repository) refs() ([]ref, error) {
+	all := map[string]ref{}
+	if err := r.readPackedRefs(all); err != nil {
+		return nil, errors.Trace(err)
+	}
+	root := filepath.Join(r.commonDir, "refs")
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return errors.Trace(err)
+		}
+		if info.IsDir() || strings.HasSuffix(path, ".lock") {
+			return nil
+		}
+		rel, err := filepath.Rel(r.commonDir, path)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		name := filepath.ToSlash(rel)
+		found, err := readLooseRef(path, name)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		all[name] = found
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read loose refs")
+	}
+	refs := make([]ref, 0, len(all))
+	for _, found := range all {
+		// replaced objects change what the commits look like.
+		if strings.HasPrefix(found.name, "refs/replace/") {
+			return nil, errNotSupported
+		}
+		refs = append(refs, found)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].name < refs[j].name })
+	return refs, nil
+}
+
+func (r *repository) readPackedRefs(refs map[string]ref) error {
+	f, err := os.Open(filepath.Join(r.commonDir, "packed-refs"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// comments hold the traits of the file and ^ the peeled
+		// value of the tag above.
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 || !isHash(fields[0]) {
+			return errors.NotValidf("packed ref %q", line)
+		}
+		refs[fields[1]] = ref{name: fields[1], hash: fields[0]}
+	}
+	return errors.Trace(scanner.Err())
+}
+
+func readLooseRef(path, name string) (ref, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ref{}, errors.Trace(err)
+	}
+	value := strings.TrimSpace(string(content))
+	if strings.HasPrefix(value, "ref: ") {
+		return ref{name: name, target: strings.TrimPrefix(value, "ref: ")}, nil
+	}
+	if !isHash(value) {
+		return ref{}, errors.NotValidf("ref %q", name)
+	}
+	return ref{name: name, hash: value}, nil
+}
+
+// head returns the ref HEAD points to, empty if detached, and the hash
+// of the commit it resolves to.
+func (r *repository) head(refs []ref) (string, string, error) {
+	found, err := readLooseRef(filepath.Join(r.gitDir, "HEAD"), "HEAD")
+	if err != nil {
+		return "", "", errors.Trace(err)
+	}
+	if found.target == "" {
+		return "", found.hash, nil
+	}
+	hash, err := resolveRef(refs, found.target)
+	return found.target, hash, errors.Trace(err)
+}
+
+// resolveRef returns the hash the named ref points to, following
+// symbolic refs.
+func resolveRef(refs []ref, name string) (string, error) {
+	// git gives up on symbolic refs nested deeper than this.
+	for depth := 0; depth < 5; depth++ {
+		i := sort.Search(len(refs), func(i int) bool { return refs[i].name >= name })
+		if i == len(refs) || refs[i].name != name {
+			return "", errors.NotFoundf("ref %q", name)
+		}
+		if refs[i].target == "" {
+			return refs[i].hash, nil
+		}
+		name = refs[i].target
+	}
+	return "", errors.NotValidf("symbolic ref %q", name)
+}
+
+// resolve returns the hash of the commit rev names, only full hashes
+// and ref names, with the same precedence git gives them, are known.
+func (r *repository) resolve(refs []ref, rev string) (string, error) {
+	if isHash(rev) {
+		return r.peel(rev)
+	}
+	if rev == "" || strings.ContainsAny(rev, "~^:@{}*?[\\ ") {
+		return "", errNotSupported
+	}
+	if rev == "HEAD" {
+		_, hash, err := r.head(refs)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		return r.peel(hash)
+	}
+	// pseudo refs, like ORIG_HEAD, are files in the git directory.
+	if strings.Trim(rev, "ABCDEFGHIJKLMNOPQRSTUVWXYZ_") == "" {
+		return "", errNotSupported
+	}
+	names := []string{"refs/" + rev, "refs/tags/" + rev, "refs/heads/" + rev, "refs/remotes/" + rev, "refs/remotes/" + rev + "/HEAD"}
+	if strings.HasPrefix(rev, "refs/") {
+		names = append([]string{rev}, names...)
+	}
+	for _, name := range names {
+		hash, err := resolveRef(refs, name)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		return r.peel(hash)
+	}
+	// abbreviated hashes and anything else.
+	return "", errNotSupported
+}
+
+// peel returns the commit hash points to, following tags.
+func (r *repository) peel(hash string) (string, error) {
+	for {
+		kind, data, err := r.objects.read(hash)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		switch kind {
+		case "commit":
+			return hash, nil
+		case "tag":
+			target := header(data, "object")
+			if !isHash(target) {
+				return "", errors.NotValidf("tag %s", hash)
+			}
+			hash = target
+		default:
+			return "", errors.NotValidf("%s %s as a commit", kind, hash)
+		}
+	}
+}
+
+// commit returns the parsed commit for hash.
+func (r *repository) commit(hash string) (*commit, error) {
+	if c, ok := r.commits[hash]; ok {
+		return c, nil
+	}
+	kind, data, err := r.objects.read(hash)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if kind != "commit" {
+		return nil, errors.NotValidf("%s %s as a commit", kind, hash)
+	}
+	c, err := parseCommit(hash, data)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// the history of shallow clones ends at the shallow commits.
+	if r.shallow[hash] {
+		c.parents = nil
+	}
+	r.commits[hash] = c
+	return c, nil
+}
+
+// upstream returns the full name of the ref the given local branch
+// tracks, as %(upstream) of for-each-ref does.
+func upstream(cfg config, branch string) string {
+	name := strings.TrimPrefix(branch, "refs/heads/")
+	remote := cfg.get("branch." + name + ".remote")
+	merge := cfg.get("branch." + name + ".merge")
+	if remote == "" || merge == "" {
+		return ""
+	}
+	if remote == "." {
+		return merge
+	}
+	for _, spec := range cfg.getAll("remote." + remote + ".fetch") {
+		spec = strings.TrimPrefix(spec, "+")
+		if strings.HasPrefix(spec, "^") {
+			continue
+		}
+		parts := strings.SplitN(spec, ":", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			continue
+		}
+		src, dst := parts[0], parts[1]
+		star := strings.Index(src, "*")
+		if star < 0 {
+			if src == merge {
+				return dst
+			}
+			continue
+		}
+		prefix, suffix := src[:star], src[star+1:]
+		if len(merge) >= len(prefix)+len(suffix) && strings.HasPrefix(merge, prefix) && strings.HasSuffix(merge, suffix) {
+			matched := merge[len(prefix) : len(merge)-len(suffix)]
+			return strings.Replace(dst, "*", matched, 1)
+		}
+	}
+	return ""
+}
+
+func isHash(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,265 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package native
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/juju/errors"
+)
+
+// slop is how many uninteresting commits git keeps walking once only
+// uninteresting ones are left, in case of clock skew.
+const slop = 5
+
+// start is a commit the history is walked from.
+type start struct {
+	hash string
+	// uninteresting excludes the commit and its ancestors, as ^hash.
+	uninteresting bool
+}
+
+// walk returns the commits reachable from the interesting starts but
+// not from the uninteresting ones in the order git log shows them,
+// newest first by committer date.
+func (r *repository) walk(starts []start, firstParent bool) ([]*commit, error) {
+	const (
+		seen = 1 << iota
+		uninteresting
+	)
+	flags := map[string]uint8{}
+	queue := &commitQueue{}
+	push := func(hash string) error {
+		c, err := r.commit(hash)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		flags[hash] |= seen
+		queue.add(c)
+		return nil
+	}
+	// markParents marks the ancestors of c already seen uninteresting,
+	// the rest will be when they are reached.
+	markParents := func(c *commit) {
+		pending := append([]string{}, c.parents...)
+		for len(pending) > 0 {
+			hash := pending[len(pending)-1]
+			pending = pending[:len(pending)-1]
+			if flags[hash]&uninteresting != 0 {
+				continue
+			}
+			flags[hash] |= uninteresting
+			if flags[hash]&seen != 0 {
+				pending = append(pending, r.commits[hash].parents...)
+			}
+		}
+	}
+	// interesting returns true while the walk can still find commits to
+	// show, lastShown is the date of the last commit shown.
+	lastShown := int64(math.MaxInt64)
+	interesting := func() bool {
+		if lastShown <= queue.peek().date {
+			return true
+		}
+		for _, q := range queue.items {
+			if flags[q.commit.hash]&uninteresting == 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, s := range starts {
+		if s.uninteresting {
+			flags[s.hash] |= uninteresting
+		}
+		if flags[s.hash]&seen != 0 {
+			continue
+		}
+		if err := push(s.hash); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	shown := []*commit{}
+	remaining := slop
+	for queue.Len() > 0 {
+		c := heap.Pop(queue).(queued).commit
+		if flags[c.hash]&uninteresting != 0 {
+			markParents(c)
+			for _, parent := range c.parents {
+				if flags[parent]&seen == 0 {
+					if err := push(parent); err != nil {
+						return nil, errors.Trace(err)
+					}
+				}
+			}
+			if queue.Len() == 0 {
+				break
+			}
+			if interesting() {
+				remaining = slop
+				continue
+			}
+			if remaining--; remaining == 0 {
+				break
+			}
+			continue
+		}
+		shown = append(shown, c)
+		lastShown = c.date
+		parents := c.parents
+		if firstParent && len(parents) > 1 {
+			parents = parents[:1]
+		}
+		for _, parent := range parents {
+			if flags[parent]&seen == 0 {
+				if err := push(parent); err != nil {
+					return nil, errors.Trace(err)
+				}
+			}
+		}
+	}
+	// commits shown early might have turned out to be uninteresting.
+	result := shown[:0]
+	for _, c := range shown {
+		if flags[c.hash]&uninteresting == 0 {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+// symmetricDifference returns how many commits are reachable only from
+// left and how many only from right, as rev-list --left-right --count
+// left...right does. Like git it stops once only common ancestors are
+// left, walking a few more in case of clock skew.
+func (r *repository) symmetricDifference(left, right string) (int, int, error) {
+	const (
+		leftSide = 1 << iota
+		rightSide
+		bothSides = leftSide | rightSide
+	)
+	flags := map[string]uint8{}
+	queue := &commitQueue{}
+	// paint adds side to the commit and walks it again if it was new.
+	paint := func(hash string, side uint8) error {
+		if flags[hash]&side == side {
+			return nil
+		}
+		c, err := r.commit(hash)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		flags[hash] |= side
+		queue.add(c)
+		return nil
+	}
+	if err := paint(left, leftSide); err != nil {
+		return 0, 0, errors.Trace(err)
+	}
+	if err := paint(right, rightSide); err != nil {
+		return 0, 0, errors.Trace(err)
+	}
+	// interesting returns true while the walk can still find commits
+	// only reachable from one side, lastOneSided is the date of the last
+	// commit walked that was, as lastShown in walk.
+	lastOneSided := int64(math.MaxInt64)
+	interesting := func() bool {
+		if lastOneSided <= queue.peek().date {
+			return true
+		}
+		for _, q := range queue.items {
+			if flags[q.commit.hash] != bothSides {
+				return true
+			}
+		}
+		return false
+	}
+	remaining := slop
+	for queue.Len() > 0 {
+		c := heap.Pop(queue).(queued).commit
+		for _, parent := range c.parents {
+			if err := paint(parent, flags[c.hash]); err != nil {
+				return 0, 0, errors.Trace(err)
+			}
+		}
+		if flags[c.hash] != bothSides {
+			lastOneSided = c.date
+			continue
+		}
+		if queue.Len() == 0 {
+			break
+		}
+		if interesting() {
+			remaining = slop
+			continue
+		}
+		if remaining--; remaining == 0 {
+			break
+		}
+	}
+	leftOnly, rightOnly := 0, 0
+	for _, side := range flags {
+		switch side {
+		case leftSide:
+			leftOnly++
+		case rightSide:
+			rightOnly++
+		}
+	}
+	return leftOnly, rightOnly, nil
+}
+
+type queued struct {
+	commit *commit
+	order  int
+}
+
+// commitQueue holds commits newest first by committer date, those with
+// the same date in the order they were added, as git walks them.
+type commitQueue struct {
+	items []queued
+	added int
+}
+
+func (q *commitQueue) add(c *commit) {
+	heap.Push(q, queued{commit: c, order: q.added})
+	q.added++
+}
+
+func (q *commitQueue) peek() *commit {
+	return q.items[0].commit
+}
+
+// Len implements heap.Interface.
+func (q *commitQueue) Len() int {
+	return len(q.items)
+}
+
+// Less implements heap.Interface.
+func (q *commitQueue) Less(i, j int) bool {
+	a, b := q.items[i], q.items[j]
+	if a.commit.date != b.commit.date {
+		return a.commit.date > b.commit.date
+	}
+	return a.order < b.order
+}
+
+// Swap implements heap.Interface.
+func (q *commitQueue) Swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+}
+
+// Push implements heap.Interface.
+func (q *commitQueue) Push(x interface{}) {
+	q.items = append(q.items, x.(queued))
+}
+
+// Pop implements heap.Interface.
+func (q *commitQueue) Pop() interface{} {
+	last := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return last
+}
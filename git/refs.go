@@ -0,0 +1,87 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package git
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+const (
+	localRefsPrefix  = "refs/heads/"
+	remoteRefsPrefix = "refs/remotes/"
+)
+
+// refFormat is the for-each-ref format for Ref, fields are separated
+// by NUL which cannot be part of a ref name.
+const refFormat = "--format=%(refname)%00%(upstream)%00%(HEAD)%00%(objectname)%00%(committerdate:unix)%00%(symref)"
+
+// Ref holds the information about a branch as reported by git.
+type Ref struct {
+	// Name is the short name of the branch, ie: fix_1.2_12345 for a local
+	// branch or origin/fix_1.2_12345 for a remote tracking one.
+	Name string
+	// FullName is the name of the ref, ie: refs/heads/fix_1.2_12345.
+	FullName string
+	// Upstream is the short name of the branch this one tracks, if any.
+	Upstream string
+	// Head is true for the branch currently checked out.
+	Head bool
+	Hash string
+	// Date is the committer date of the commit the branch points to.
+	Date time.Time
+}
+
+// Remote returns true for remote tracking branches.
+func (r Ref) Remote() bool {
+	return strings.HasPrefix(r.FullName, remoteRefsPrefix)
+}
+
+// parseRefs parses the output of for-each-ref with refFormat.
+func parseRefs(out string) ([]Ref, error) {
+	refs := []Ref{}
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 6 {
+			return nil, errors.Errorf("unexpected for-each-ref output %q", line)
+		}
+		// symbolic refs, like origin/HEAD, are not branches.
+		if fields[5] != "" {
+			continue
+		}
+		ref := Ref{
+			Name:     shortRefName(fields[0]),
+			FullName: fields[0],
+			Upstream: shortRefName(fields[1]),
+			Head:     fields[2] == "*",
+			Hash:     fields[3],
+		}
+		if fields[4] != "" {
+			seconds, err := strconv.ParseInt(fields[4], 10, 64)
+			if err != nil {
+				return nil, errors.Annotatef(err, "unexpected date for %q", ref.FullName)
+			}
+			ref.Date = time.Unix(seconds, 0)
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// shortRefName strips the refs/heads/ or refs/remotes/ prefix of name,
+// unlike refname:short it never adds one to disambiguate.
+func shortRefName(name string) string {
+	for _, prefix := range []string{localRefsPrefix, remoteRefsPrefix} {
+		if strings.HasPrefix(name, prefix) {
+			return strings.TrimPrefix(name, prefix)
+		}
+	}
+	return name
+}
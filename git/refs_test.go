@@ -0,0 +1,48 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package git
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRefs(t *testing.T) {
+	out := strings.Join([]string{
+		"refs/heads/fix_1.2_12345\x00refs/remotes/origin/fix_1.2_12345\x00*\x00a1b2\x001400000000\x00",
+		"refs/heads/+weird\x00\x00 \x00c3d4\x001400000001\x00",
+		"refs/remotes/origin/HEAD\x00\x00 \x00a1b2\x001400000000\x00refs/remotes/origin/master",
+		"refs/remotes/origin/release/1.2\x00\x00 \x00e5f6\x00\x00",
+	}, "\n")
+	refs, err := parseRefs(out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Ref{
+		{Name: "fix_1.2_12345", FullName: "refs/heads/fix_1.2_12345", Upstream: "origin/fix_1.2_12345",
+			Head: true, Hash: "a1b2", Date: time.Unix(1400000000, 0)},
+		{Name: "+weird", FullName: "refs/heads/+weird", Hash: "c3d4", Date: time.Unix(1400000001, 0)},
+		{Name: "origin/release/1.2", FullName: "refs/remotes/origin/release/1.2", Hash: "e5f6"},
+	}
+	if len(refs) != len(expected) {
+		t.Fatalf("expected %d refs got %d: %#v", len(expected), len(refs), refs)
+	}
+	for i := range expected {
+		if refs[i] != expected[i] {
+			t.Logf("expected %#v got %#v", expected[i], refs[i])
+			t.Fail()
+		}
+	}
+	if refs[0].Remote() || !refs[2].Remote() {
+		t.Logf("remote tracking branches misidentified")
+		t.Fail()
+	}
+}
+
+func TestParseRefsInvalid(t *testing.T) {
+	if refs, err := parseRefs("refs/heads/master"); err == nil {
+		t.Fatalf("expected an error for malformed output, got %#v", refs)
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// Repo provides typed access to the state of a repository on top of
+// the git calls made by its constructor.
+type Repo struct {
+	newGit CompatibleConstructor
+}
+
+// NewRepo returns a Repo that calls git with newGit.
+func NewRepo(newGit CompatibleConstructor) *Repo {
+	return &Repo{newGit: newGit}
+}
+
+// output runs the given git sub command and returns its output.
+func (r *Repo) output(subCommand string, args ...string) (string, error) {
+	c := r.newGit(subCommand, args)
+	cmd, err := c.Git()
+	if err != nil {
+		return "", errors.Annotate(err, "cannot create git command caller")
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return string(out), nil
+}
+
+// CurrentBranch returns the name of the branch currently checked out,
+// HEAD if there is none.
+func (r *Repo) CurrentBranch() (string, error) {
+	branch, err := r.output("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", errors.Annotate(err, "cannot determine current branch")
+	}
+	return strings.TrimSpace(branch), nil
+}
+
+// Branches returns the local branches.
+func (r *Repo) Branches() ([]Ref, error) {
+	refs, err := r.forEachRef(strings.TrimSuffix(localRefsPrefix, "/"))
+	return refs, errors.Annotate(err, "cannot list local branches")
+}
+
+// RemoteBranches returns the remote tracking branches, such as
+// origin/master, omitting symbolic ones like origin/HEAD.
+func (r *Repo) RemoteBranches() ([]Ref, error) {
+	refs, err := r.forEachRef(strings.TrimSuffix(remoteRefsPrefix, "/"))
+	return refs, errors.Annotate(err, "cannot list remote branches")
+}
+
+func (r *Repo) forEachRef(pattern string) ([]Ref, error) {
+	out, err := r.output("for-each-ref", refFormat, pattern)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return parseRefs(strings.TrimSpace(out))
+}
+
+// Remotes returns the names of the configured remotes, which unlike
+// branch names might contain slashes.
+func (r *Repo) Remotes() ([]string, error) {
+	out, err := r.output("remote")
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot list remotes")
+	}
+	return strings.Fields(out), nil
+}
+
+// Status returns the paths with changes in the working tree.
+func (r *Repo) Status() (Status, error) {
+	out, err := r.output("status", "--porcelain", "-z", "--untracked-files=all")
+	if err != nil {
+		return Status{}, errors.Annotate(err, "cannot determine working tree status")
+	}
+	return parseStatus(out)
+}
+
+// Log returns the commits listed by git log for the given args, such
+// as a range like master..fix_master_1, newest first.
+func (r *Repo) Log(args ...string) ([]Commit, error) {
+	args = append([]string{logFormat}, args...)
+	out, err := r.output("log", args...)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read git log")
+	}
+	return parseLog(out), nil
+}
+
+// RefExists returns true if ref, such as refs/heads/master or
+// CHERRY_PICK_HEAD, exists.
+func (r *Repo) RefExists(ref string) (bool, error) {
+	if _, err := r.output("rev-parse", "--verify", "--quiet", ref); err != nil {
+		// rev-parse --verify --quiet exits with 1 for unknown refs.
+		if ExitCode(err) == 1 {
+			return false, nil
+		}
+		return false, errors.Annotatef(err, "cannot verify %q", ref)
+	}
+	return true, nil
+}
+
+// BranchExists returns true if there is a local branch with the given name.
+func (r *Repo) BranchExists(branch string) (bool, error) {
+	exists, err := r.RefExists(localRefsPrefix + branch)
+	return exists, errors.Trace(err)
+}
+
+// LastCommit returns a summary of the commit ref points to.
+func (r *Repo) LastCommit(ref string) (CommitSummary, error) {
+	out, err := r.output("log", "-1", summaryFormat, ref)
+	if err != nil {
+		return CommitSummary{}, errors.Annotatef(err, "cannot read last commit of %q", ref)
+	}
+	fields := strings.SplitN(strings.TrimSpace(out), "\x00", 2)
+	if len(fields) != 2 {
+		return CommitSummary{}, errors.Errorf("unexpected log output for %q: %q", ref, out)
+	}
+	return CommitSummary{Age: fields[0], Subject: fields[1]}, nil
+}
+
+// AheadBehind returns how many commits branch has that target does not
+// and how many target has that branch does not.
+func (r *Repo) AheadBehind(target, branch string) (int, int, error) {
+	out, err := r.output("rev-list", "--left-right", "--count", target+"..."+branch)
+	if err != nil {
+		return 0, 0, errors.Annotatef(err, "cannot compare %q with %q", branch, target)
+	}
+	var ahead, behind int
+	if _, err := fmt.Sscanf(out, "%d %d", &behind, &ahead); err != nil {
+		return 0, 0, errors.Annotatef(err, "unexpected rev-list output %q", out)
+	}
+	return ahead, behind, nil
+}
+
+// MergeBase returns the best common ancestor of the two given commits.
+func (r *Repo) MergeBase(a, b string) (string, error) {
+	base, err := r.output("merge-base", a, b)
+	if err != nil {
+		return "", errors.Annotatef(err, "cannot find a common ancestor for %q and %q", a, b)
+	}
+	return strings.TrimSpace(base), nil
+}
+
+// IsAncestor returns true if commit a is an ancestor of commit b.
+func (r *Repo) IsAncestor(a, b string) (bool, error) {
+	if _, err := r.output("merge-base", "--is-ancestor", a, b); err != nil {
+		// merge-base --is-ancestor exits with 1 when it is not.
+		if ExitCode(err) == 1 {
+			return false, nil
+		}
+		return false, errors.Annotatef(err, "cannot determine if %q is an ancestor of %q", a, b)
+	}
+	return true, nil
+}
+
+// ConfigGet returns the value of the given git config key, an unset key
+// yields an empty string.
+func (r *Repo) ConfigGet(key string) (string, error) {
+	value, err := r.output(SCMDConfig, "--get", key)
+	if err != nil {
+		// git config exits with 1 when the key is not set.
+		if ExitCode(err) == 1 {
+			return "", nil
+		}
+		return "", errors.Annotatef(err, "cannot read git config %q", key)
+	}
+	return strings.TrimSpace(value), nil
+}
+
+// ConfigGetAll returns all the values of the given multi-valued git
+// config key, an unset key yields no values.
+func (r *Repo) ConfigGetAll(key string) ([]string, error) {
+	out, err := r.output(SCMDConfig, "--get-all", key)
+	if err != nil {
+		// git config exits with 1 when the key is not set.
+		if ExitCode(err) == 1 {
+			return nil, nil
+		}
+		return nil, errors.Annotatef(err, "cannot read git config %q", key)
+	}
+	values := []string{}
+	for _, value := range strings.Split(out, "\n") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
+
+// ConfigSet sets the given git config key for the repository.
+func (r *Repo) ConfigSet(key, value string) error {
+	if _, err := r.output(SCMDConfig, key, value); err != nil {
+		return errors.Annotatef(err, "cannot set git config %q", key)
+	}
+	return nil
+}
+
+// ConfigUnset removes the given git config key from the repository,
+// removing an unset key is not an error.
+func (r *Repo) ConfigUnset(key string) error {
+	if _, err := r.output(SCMDConfig, "--unset", key); err != nil {
+		// git config exits with 5 when the key is not set.
+		if ExitCode(err) == 5 {
+			return nil
+		}
+		return errors.Annotatef(err, "cannot unset git config %q", key)
+	}
+	return nil
+}
+
+// DiffPatchID returns the patch id of the changes between the commits
+// from and to, empty if there are none.
+func (r *Repo) DiffPatchID(from, to string) (string, error) {
+	patch, err := r.output("diff", "--no-color", "--no-ext-diff", from, to)
+	if err != nil {
+		return "", errors.Annotatef(err, "cannot diff %q with %q", from, to)
+	}
+	ids, err := r.patchIDs(patch)
+	if err != nil || len(ids) == 0 {
+		return "", errors.Trace(err)
+	}
+	return ids[0], nil
+}
+
+// LogPatchIDs returns the patch ids of the commits listed by git log
+// for the given args, merges have none.
+func (r *Repo) LogPatchIDs(args ...string) ([]string, error) {
+	args = append([]string{"-p", "--no-color", "--no-ext-diff", "--format=commit %H"}, args...)
+	patch, err := r.output("log", args...)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read git log")
+	}
+	return r.patchIDs(patch)
+}
+
+// patchIDs returns the patch ids git computes for the given patch, the
+// same changes have the same id no matter which commit they are in.
+func (r *Repo) patchIDs(patch string) ([]string, error) {
+	if patch == "" {
+		return nil, nil
+	}
+	c := r.newGit("patch-id", []string{"--stable"})
+	opts := c.Options()
+	opts.Stdin = strings.NewReader(patch)
+	c.SetOptions(opts)
+	cmd, err := c.Git()
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot create git command caller")
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot compute patch ids")
+	}
+	ids := []string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			ids = append(ids, fields[0])
+		}
+	}
+	return ids, nil
+}
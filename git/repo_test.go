@@ -0,0 +1,110 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package git_test
+
+import (
+	"testing"
+
+	"github.com/perrito666/got/git"
+	gtesting "github.com/perrito666/got/testing"
+)
+
+func TestRepoExitCodes(t *testing.T) {
+	tests := map[string]struct {
+		key string
+		// query runs the Repo method and returns what it found.
+		query func(*git.Repo) (interface{}, error)
+		// results maps the exit code of git to the expected result, a
+		// missing exit code is expected to be an error.
+		results map[int]interface{}
+	}{
+		"IsAncestor": {
+			key:     "merge-base --is-ancestor",
+			query:   func(r *git.Repo) (interface{}, error) { return r.IsAncestor("a", "b") },
+			results: map[int]interface{}{0: true, 1: false},
+		},
+		"RefExists": {
+			key:     "rev-parse --verify",
+			query:   func(r *git.Repo) (interface{}, error) { return r.RefExists("CHERRY_PICK_HEAD") },
+			results: map[int]interface{}{0: true, 1: false},
+		},
+		"BranchExists": {
+			key:     "rev-parse --verify refs/heads/fix_1.2_1",
+			query:   func(r *git.Repo) (interface{}, error) { return r.BranchExists("fix_1.2_1") },
+			results: map[int]interface{}{0: true, 1: false},
+		},
+		"ConfigGet": {
+			key:     "config --get got.fix.default",
+			query:   func(r *git.Repo) (interface{}, error) { return r.ConfigGet("got.fix.default") },
+			results: map[int]interface{}{0: "1.2", 1: ""},
+		},
+		"ConfigUnset": {
+			key: "config --unset got.port.source",
+			query: func(r *git.Repo) (interface{}, error) {
+				return nil, r.ConfigUnset("got.port.source")
+			},
+			results: map[int]interface{}{0: nil, 5: nil},
+		},
+	}
+	for name, test := range tests {
+		for _, code := range []int{0, 1, 5, 128} {
+			script := &gtesting.ScriptedGit{Responses: map[string]gtesting.Response{
+				test.key: {Output: "1.2\n", ExitCode: code, Stderr: "fatal: boom"},
+			}}
+			got, err := test.query(git.NewRepo(script.New))
+			expected, ok := test.results[code]
+			if !ok {
+				if err == nil || git.ExitCode(err) != code {
+					t.Logf("%s: expected exit code %d to be an error, got %v", name, code, got)
+					t.Fail()
+				}
+				continue
+			}
+			if err != nil {
+				t.Logf("%s: unexpected error for exit code %d: %v", name, code, err)
+				t.Fail()
+				continue
+			}
+			if got != expected {
+				t.Logf("%s: expected %v for exit code %d got %v", name, expected, code, got)
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestAheadBehind(t *testing.T) {
+	tests := map[string]struct {
+		output         string
+		ahead, behind  int
+		expectingError bool
+	}{
+		"both":    {output: "3\t2\n", ahead: 2, behind: 3},
+		"ahead":   {output: "0\t7\n", ahead: 7},
+		"garbage": {output: "fatal\n", expectingError: true},
+		"empty":   {output: "", expectingError: true},
+	}
+	for name, test := range tests {
+		script := &gtesting.ScriptedGit{Responses: map[string]gtesting.Response{
+			"rev-list --left-right --count master...fix_master_1": {Output: test.output},
+		}}
+		ahead, behind, err := git.NewRepo(script.New).AheadBehind("master", "fix_master_1")
+		if test.expectingError {
+			if err == nil {
+				t.Logf("%s: expected an error for %q", name, test.output)
+				t.Fail()
+			}
+			continue
+		}
+		if err != nil {
+			t.Logf("%s: unexpected error: %v", name, err)
+			t.Fail()
+			continue
+		}
+		if ahead != test.ahead || behind != test.behind {
+			t.Logf("%s: expected %d ahead %d behind got %d ahead %d behind", name, test.ahead, test.behind, ahead, behind)
+			t.Fail()
+		}
+	}
+}
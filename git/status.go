@@ -0,0 +1,65 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package git
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// Status holds the paths with changes in the working tree.
+type Status struct {
+	// Staged paths have changes in the index.
+	Staged []string
+	// Unstaged paths have changes in the working tree not in the index.
+	Unstaged []string
+	// Untracked paths are not known to git and not ignored.
+	Untracked []string
+	// Conflicted paths are unmerged.
+	Conflicted []string
+}
+
+// Clean returns true if there are no changes to tracked paths,
+// untracked paths do not get in the way of switching branches.
+func (s Status) Clean() bool {
+	return len(s.Staged) == 0 && len(s.Unstaged) == 0 && len(s.Conflicted) == 0
+}
+
+// parseStatus parses the output of git status --porcelain -z.
+func parseStatus(out string) (Status, error) {
+	status := Status{}
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if entry == "" {
+			continue
+		}
+		if len(entry) < 4 || entry[2] != ' ' {
+			return Status{}, errors.Errorf("unexpected status entry %q", entry)
+		}
+		x, y, path := entry[0], entry[1], entry[3:]
+		switch {
+		case x == '?' && y == '?':
+			status.Untracked = append(status.Untracked, path)
+			continue
+		case x == '!' && y == '!':
+			continue
+		case x == 'U' || y == 'U' || (x == 'A' && y == 'A') || (x == 'D' && y == 'D'):
+			status.Conflicted = append(status.Conflicted, path)
+			continue
+		}
+		if x != ' ' {
+			status.Staged = append(status.Staged, path)
+		}
+		if y != ' ' {
+			status.Unstaged = append(status.Unstaged, path)
+		}
+		// renames and copies are followed by the original path.
+		if x == 'R' || x == 'C' {
+			i++
+		}
+	}
+	return status, nil
+}
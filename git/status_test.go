@@ -0,0 +1,33 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	out := "M  staged.go\x00 M unstaged.go\x00MM both.go\x00R  new.go\x00old.go\x00" +
+		"UU conflict.go\x00AA added.go\x00?? untracked file.go\x00"
+	status, err := parseStatus(out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := Status{
+		Staged:     []string{"staged.go", "both.go", "new.go"},
+		Unstaged:   []string{"unstaged.go", "both.go"},
+		Untracked:  []string{"untracked file.go"},
+		Conflicted: []string{"conflict.go", "added.go"},
+	}
+	if !reflect.DeepEqual(status, expected) {
+		t.Fatalf("expected %#v got %#v", expected, status)
+	}
+	if status.Clean() {
+		t.Fatal("expected a status with changes not to be clean")
+	}
+	if clean, _ := parseStatus("?? untracked.go\x00"); !clean.Clean() {
+		t.Fatal("expected untracked files not to make the tree dirty")
+	}
+}
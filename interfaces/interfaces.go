@@ -3,7 +3,32 @@
 
 package interfaces
 
+// Validator checks a user input, returning an error explaining
+// why it is not acceptable if so.
+type Validator func(string) error
+
+// Item is one of the choices presented to the user, the description
+// is an optional detail shown next to the name.
+type Item struct {
+	Name        string
+	Description string
+}
+
+// NamedItems returns items with the given names and no description.
+func NamedItems(names []string) []Item {
+	items := make([]Item, len(names))
+	for i, name := range names {
+		items[i] = Item{Name: name}
+	}
+	return items
+}
+
 // UI reprsents a CLI/GUI
 type UI interface {
-	ChoiceMenu(items []string, one bool, curent int) ([]int, error)
+	// ChoiceMenu lets the user choose one or many of items, those
+	// in current are preselected.
+	ChoiceMenu(items []Item, one bool, current []int) ([]int, error)
+	Input(prompt string) (string, error)
+	Confirm(prompt string) (bool, error)
+	ValidatedInput(prompt string, validate Validator) (string, error)
 }
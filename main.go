@@ -5,26 +5,55 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
+	"os"
 
 	"github.com/perrito666/got/bug"
+	"github.com/perrito666/got/cli"
 	"github.com/perrito666/got/feature"
 	"github.com/perrito666/got/git"
+	"github.com/perrito666/got/git/native"
 	"github.com/perrito666/got/registry"
+	"github.com/perrito666/got/workitem"
 )
 
 // Interface Checking
 var _ registry.Command = &bug.Command{}
 var _ registry.Command = &feature.Command{}
+var _ registry.Command = &workitem.Command{}
 
 var args []string
 
+var plainUI bool
+
+var backend string
+
+var dryRun bool
+
 func init() {
+	flag.BoolVar(&plainUI, "plain", false, "use a line based UI instead of ncurses menus.")
+	flag.BoolVar(&dryRun, "dry-run", false, "print the git commands that would change the repository instead of running them.")
+	flag.StringVar(&backend, "backend", "exec", "how got reads the repository: exec always runs git, native reads branches, ahead/behind counts and logs itself.")
 	flag.Parse()
 	args = flag.Args()
 }
 
 func main() {
+	cli.Plain = plainUI
+	var recorder *git.Recorder
+	if dryRun {
+		git.DryRun = true
+		recorder = git.NewRecorder(os.Stdout)
+		git.Backend = git.NewWithOptions(git.Options{Craft: recorder.Craft})
+	}
+	switch backend {
+	case "exec":
+	case "native":
+		git.Backend = native.New(git.Backend)
+	default:
+		log.Fatalf("unknown backend %q, use exec or native", backend)
+	}
 	if len(args) == 0 {
 		// error is ignored here because calling git without
 		// arguments returns 1.
@@ -33,14 +62,22 @@ func main() {
 		_ = c.Run()
 		return
 	}
+	// the work branch types declared in the repository get their own command.
+	if err := workitem.Register(git.Backend); err != nil {
+		log.Println(err)
+	}
 	commands := registry.Commands()
 	command, ok := commands[args[0]]
 	if !ok {
-		c := git.New(args[0], args[1:])
-		err := c.Run()
-		if err != nil {
+		c := git.Backend(args[0], args[1:])
+		if err := c.Run(); err != nil {
+			// git already explained what went wrong.
+			if code := git.ExitCode(err); code > 0 {
+				os.Exit(code)
+			}
 			log.Fatalln(err)
 		}
+		reportDryRun(recorder)
 		return
 	}
 	c, err := command()
@@ -49,7 +86,23 @@ func main() {
 	}
 
 	if err := c.Run(args[1:]); err != nil {
-		log.Fatal(err)
+		log.Fatal(git.FriendlyMessage(err))
 	}
+	reportDryRun(recorder)
+}
 
+// reportDryRun tells what was left undone by a dry run, if this is one.
+func reportDryRun(recorder *git.Recorder) {
+	if recorder == nil {
+		return
+	}
+	if len(recorder.Skipped) == 0 {
+		fmt.Println("dry run: nothing in the repository would change.")
+		return
+	}
+	commands := "commands were"
+	if len(recorder.Skipped) == 1 {
+		commands = "command was"
+	}
+	fmt.Printf("dry run: %d git %s not run, the repository was not changed.\n", len(recorder.Skipped), commands)
 }
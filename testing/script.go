@@ -0,0 +1,192 @@
+package testing
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/perrito666/got/git"
+)
+
+// Response is the answer of a ScriptedGit call.
+type Response struct {
+	Output   string
+	ExitCode int
+	Stderr   string
+}
+
+// ScriptedGit answers git calls with the Responses scripted for them.
+// A response is keyed by the words of the call it answers, ie:
+// "rev-parse --abbrev-ref HEAD", a key also matches calls with more
+// words as long as it starts with the same sub-command and its words
+// appear in order, so "for-each-ref refs/heads" matches regardless of
+// the format. The key with most words wins. Calls without a response
+// succeed with no output. What a call reads from Stdin is its last
+// word, ie: "patch-id --stable <patch>".
+type ScriptedGit struct {
+	Responses map[string]Response
+	// Calls records every call made, as its words joined by spaces.
+	Calls []string
+}
+
+// New is a git.CompatibleConstructor that answers from the script.
+func (s *ScriptedGit) New(sub string, args []string) git.Compatible {
+	return &scriptedCall{script: s, subCommand: sub, args: args}
+}
+
+// Branch describes a local branch listed by AddBranches.
+type Branch struct {
+	// Name is the name of the branch, ie: fix_1.2_1.
+	Name string
+	// Upstream is the remote branch it tracks, ie: origin/fix_1.2_1.
+	Upstream string
+	// Current is true for the branch checked out.
+	Current bool
+}
+
+// AddBranches scripts the for-each-ref calls listing the given local
+// and remote branches, remotes are named as in origin/fix_1.2_1 and a
+// remote HEAD is listed as the symbolic ref git makes of it.
+func (s *ScriptedGit) AddBranches(local []Branch, remote []string) {
+	if s.Responses == nil {
+		s.Responses = map[string]Response{}
+	}
+	heads := ""
+	for _, branch := range local {
+		upstream, current := "", " "
+		if branch.Upstream != "" {
+			upstream = "refs/remotes/" + branch.Upstream
+		}
+		if branch.Current {
+			current = "*"
+		}
+		heads += strings.Join([]string{"refs/heads/" + branch.Name, upstream, current, "", "", ""}, "\x00") + "\n"
+	}
+	remotes := ""
+	for _, name := range remote {
+		symref := ""
+		if strings.HasSuffix(name, "/HEAD") {
+			symref = "refs/remotes/" + strings.TrimSuffix(name, "HEAD") + "master"
+		}
+		remotes += strings.Join([]string{"refs/remotes/" + name, "", " ", "", "", symref}, "\x00") + "\n"
+	}
+	s.Responses["for-each-ref refs/heads"] = Response{Output: heads}
+	s.Responses["for-each-ref refs/remotes"] = Response{Output: remotes}
+}
+
+// CallsTo returns the recorded calls of the given sub-commands, ie:
+// CallsTo("checkout", "branch") for the branches created and switched.
+func (s *ScriptedGit) CallsTo(subCommands ...string) []string {
+	calls := []string{}
+	for _, call := range s.Calls {
+		for _, sub := range subCommands {
+			if strings.SplitN(call, " ", 2)[0] == sub {
+				calls = append(calls, call)
+				break
+			}
+		}
+	}
+	return calls
+}
+
+func (s *ScriptedGit) respond(sub string, args []string, opts git.Options) ([]byte, error) {
+	words := append([]string{sub}, args...)
+	if opts.Stdin != nil {
+		input, err := ioutil.ReadAll(opts.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, string(input))
+	}
+	s.Calls = append(s.Calls, strings.Join(words, " "))
+	best, bestWords := Response{}, 0
+	for key, response := range s.Responses {
+		keyWords := strings.Fields(key)
+		if len(keyWords) > bestWords && matches(keyWords, words) {
+			best, bestWords = response, len(keyWords)
+		}
+	}
+	if best.ExitCode != 0 {
+		return []byte(best.Output), &git.Error{
+			SubCommand: sub,
+			Args:       args,
+			ExitCode:   best.ExitCode,
+			Stderr:     best.Stderr,
+		}
+	}
+	return []byte(best.Output), nil
+}
+
+// matches returns true if key starts with the same word as words and
+// all of its words appear in words in the same order.
+func matches(key, words []string) bool {
+	if len(key) == 0 || len(words) == 0 || key[0] != words[0] {
+		return false
+	}
+	i := 1
+	for _, word := range words[1:] {
+		if i < len(key) && word == key[i] {
+			i++
+		}
+	}
+	return i == len(key)
+}
+
+type scriptedCall struct {
+	script     *ScriptedGit
+	subCommand string
+	args       []string
+	options    git.Options
+}
+
+type scriptedCmd struct {
+	call *scriptedCall
+}
+
+// Run implements git.ExecCmd
+func (c *scriptedCmd) Run() error {
+	_, err := c.call.script.respond(c.call.subCommand, c.call.args, c.call.options)
+	return err
+}
+
+// Output implements git.ExecCmd
+func (c *scriptedCmd) Output() ([]byte, error) {
+	return c.call.script.respond(c.call.subCommand, c.call.args, c.call.options)
+}
+
+func (c *scriptedCall) Git() (git.ExecCmd, error) {
+	return &scriptedCmd{call: c}, nil
+}
+
+func (c *scriptedCall) Run() error {
+	_, err := c.script.respond(c.subCommand, c.args, c.options)
+	return err
+}
+
+func (c *scriptedCall) Checkout(branch string) error {
+	_, err := c.script.respond(git.SCMDCheckout, []string{branch}, c.options)
+	return err
+}
+
+func (c *scriptedCall) SubCommand() string {
+	return c.subCommand
+}
+
+func (c *scriptedCall) SetSubCommand(s string) {
+	c.subCommand = s
+}
+
+func (c *scriptedCall) Args() []string {
+	return c.args
+}
+
+func (c *scriptedCall) SetArgs(a []string) {
+	c.args = a
+}
+
+func (c *scriptedCall) Options() git.Options {
+	return c.options
+}
+
+func (c *scriptedCall) SetOptions(o git.Options) {
+	c.options = o
+}
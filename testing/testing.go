@@ -1,6 +1,10 @@
 package testing
 
-import "github.com/perrito666/got/git"
+import (
+	"github.com/juju/errors"
+	"github.com/perrito666/got/git"
+	"github.com/perrito666/got/interfaces"
+)
 
 type fakeCmd struct {
 	ran int
@@ -45,13 +49,71 @@ func (f *FakeGit) Args() []string {
 func (f *FakeGit) SetArgs([]string) {
 }
 
+func (f *FakeGit) Options() git.Options {
+	return git.Options{}
+}
+
+func (f *FakeGit) SetOptions(git.Options) {
+}
+
 func New(s string, a []string) git.Compatible {
 	return &FakeGit{&fakeCmd{}}
 }
 
+// FakeUI is an interfaces.UI that answers with the scripted Choices,
+// Inputs and Confirms in order, once exhausted it answers as if the
+// user had cancelled.
 type FakeUI struct {
+	Choices [][]int
+	// Currents records the preselection of every ChoiceMenu shown.
+	Currents [][]int
+	Inputs   []string
+	Confirms []bool
+	// Prompts records every prompt shown to the user.
+	Prompts []string
 }
 
-func (*FakeUI) ChoiceMenu(items []string, one bool, curent int) ([]int, error) {
-	return nil, nil
+func (f *FakeUI) ChoiceMenu(items []interfaces.Item, one bool, current []int) ([]int, error) {
+	f.Currents = append(f.Currents, current)
+	if len(f.Choices) == 0 {
+		return nil, nil
+	}
+	choice := f.Choices[0]
+	f.Choices = f.Choices[1:]
+	return choice, nil
+}
+
+func (f *FakeUI) Input(prompt string) (string, error) {
+	f.Prompts = append(f.Prompts, prompt)
+	if len(f.Inputs) == 0 {
+		return "", nil
+	}
+	input := f.Inputs[0]
+	f.Inputs = f.Inputs[1:]
+	return input, nil
+}
+
+func (f *FakeUI) Confirm(prompt string) (bool, error) {
+	f.Prompts = append(f.Prompts, prompt)
+	if len(f.Confirms) == 0 {
+		return false, nil
+	}
+	confirm := f.Confirms[0]
+	f.Confirms = f.Confirms[1:]
+	return confirm, nil
+}
+
+func (f *FakeUI) ValidatedInput(prompt string, validate interfaces.Validator) (string, error) {
+	for {
+		if len(f.Inputs) == 0 {
+			return "", errors.New("no valid input scripted")
+		}
+		input, err := f.Input(prompt)
+		if err != nil {
+			return "", err
+		}
+		if validate(input) == nil {
+			return input, nil
+		}
+	}
 }
@@ -0,0 +1,150 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package tracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/perrito666/got/git"
+)
+
+const (
+	// LaunchpadName is the name used to configure launchpad as the tracker.
+	LaunchpadName = "launchpad"
+	// GitHubName is the name used to configure github as the tracker.
+	GitHubName = "github"
+
+	// trackerKey is the git config key holding the tracker of a repository,
+	// either launchpad, github or github:<owner>/<repo>.
+	trackerKey = "got.tracker"
+	originKey  = "remote.origin.url"
+)
+
+// Tracker represents an issue tracker.
+type Tracker interface {
+	// URL returns the link to the given bug in the tracker.
+	URL(id string) string
+}
+
+// Titler is implemented by the trackers that can tell the title of a bug.
+type Titler interface {
+	Title(id string) (string, error)
+}
+
+// Launchpad is the launchpad.net issue tracker.
+type Launchpad struct{}
+
+// URL implements Tracker.
+func (Launchpad) URL(id string) string {
+	return fmt.Sprintf("https://bugs.launchpad.net/bugs/%s", id)
+}
+
+// Title implements Titler.
+func (Launchpad) Title(id string) (string, error) {
+	return fetchTitle(fmt.Sprintf("https://api.launchpad.net/devel/bugs/%s", id))
+}
+
+// GitHub is the issue tracker of a github repository.
+type GitHub struct {
+	Owner string
+	Repo  string
+}
+
+// URL implements Tracker.
+func (g GitHub) URL(id string) string {
+	return fmt.Sprintf("https://github.com/%s/%s/issues/%s", g.Owner, g.Repo, id)
+}
+
+// Title implements Titler.
+func (g GitHub) Title(id string) (string, error) {
+	return fetchTitle(fmt.Sprintf("https://api.github.com/repos/%s/%s/issues/%s", g.Owner, g.Repo, id))
+}
+
+// client fetches from the trackers, one that does not answer in time is
+// treated as unreachable instead of leaving got hanging.
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// fetchTitle reads the title field of the json document at url, both
+// launchpad and github use it for the bug title.
+func fetchTitle(url string) (string, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", errors.Annotatef(err, "cannot reach %q", url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("cannot fetch %q: %s", url, resp.Status)
+	}
+	bug := struct {
+		Title string `json:"title"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&bug); err != nil {
+		return "", errors.Annotatef(err, "cannot decode %q", url)
+	}
+	return bug.Title, nil
+}
+
+// FromConfig returns the tracker set in the repository config or, if
+// there is none, the one inferred from the origin remote.
+func FromConfig(newGit git.CompatibleConstructor) (Tracker, error) {
+	configured, err := git.ConfigGet(newGit, trackerKey)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	origin, err := git.ConfigGet(newGit, originKey)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if configured != "" {
+		return Named(configured, origin)
+	}
+	if origin == "" {
+		return nil, errors.NotFoundf("issue tracker (set %q or an origin remote)", trackerKey)
+	}
+	return FromRemote(origin)
+}
+
+// Named returns the tracker for the given name, origin is used to
+// determine the github repository when not specified in the name.
+func Named(name, origin string) (Tracker, error) {
+	parts := strings.SplitN(name, ":", 2)
+	switch parts[0] {
+	case LaunchpadName:
+		return Launchpad{}, nil
+	case GitHubName:
+		if len(parts) == 2 {
+			return gitHubFromPath(parts[1])
+		}
+		return FromRemote(origin)
+	}
+	return nil, errors.NotSupportedf("issue tracker %q", name)
+}
+
+var gitHubRemote = regexp.MustCompile(`github\.com[:/](.+)$`)
+
+// FromRemote infers the tracker from the url of a git remote.
+func FromRemote(url string) (Tracker, error) {
+	if strings.HasPrefix(url, "lp:") || strings.Contains(url, "launchpad.net") {
+		return Launchpad{}, nil
+	}
+	if match := gitHubRemote.FindStringSubmatch(url); match != nil {
+		return gitHubFromPath(match[1])
+	}
+	return nil, errors.NotSupportedf("issue tracker for remote %q", url)
+}
+
+func gitHubFromPath(path string) (Tracker, error) {
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, errors.NotValidf("github repository %q", path)
+	}
+	return GitHub{Owner: parts[0], Repo: parts[1]}, nil
+}
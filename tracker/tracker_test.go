@@ -0,0 +1,90 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package tracker
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFromRemote(t *testing.T) {
+	tests := []struct {
+		remote   string
+		expected string
+	}{
+		{"git@github.com:perrito666/got.git", "https://github.com/perrito666/got/issues/1"},
+		{"https://github.com/perrito666/got", "https://github.com/perrito666/got/issues/1"},
+		{"https://github.com/perrito666/got.git/", "https://github.com/perrito666/got/issues/1"},
+		{"ssh://git@github.com/perrito666/got.git", "https://github.com/perrito666/got/issues/1"},
+		{"git+ssh://git.launchpad.net/juju", "https://bugs.launchpad.net/bugs/1"},
+		{"lp:juju-core", "https://bugs.launchpad.net/bugs/1"},
+	}
+	for _, test := range tests {
+		tr, err := FromRemote(test.remote)
+		if err != nil {
+			t.Logf("unexpected error for %q: %v", test.remote, err)
+			t.Fail()
+			continue
+		}
+		if url := tr.URL("1"); url != test.expected {
+			t.Logf("expected %q for %q got %q", test.expected, test.remote, url)
+			t.Fail()
+		}
+	}
+}
+
+func TestFromRemoteUnknown(t *testing.T) {
+	if _, err := FromRemote("https://example.com/repo.git"); err == nil {
+		t.Fatal("expected an error for an unknown tracker")
+	}
+}
+
+func TestNamed(t *testing.T) {
+	tr, err := Named("github:juju/juju", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if url := tr.URL("42"); url != "https://github.com/juju/juju/issues/42" {
+		t.Fatalf("unexpected url %q", url)
+	}
+	tr, err = Named("github", "git@github.com:perrito666/got.git")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if url := tr.URL("42"); url != "https://github.com/perrito666/got/issues/42" {
+		t.Fatalf("unexpected url %q", url)
+	}
+	if _, err := Named("bugzilla", ""); err == nil {
+		t.Fatal("expected an error for an unsupported tracker")
+	}
+}
+
+func TestFetchTitle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"title": "Crash on startup"}`)
+	}))
+	defer server.Close()
+	title, err := fetchTitle(server.URL)
+	if err != nil || title != "Crash on startup" {
+		t.Fatalf("expected the title got %q (%v)", title, err)
+	}
+}
+
+func TestFetchTitleTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+	defer func(timeout time.Duration) { client.Timeout = timeout }(client.Timeout)
+	client.Timeout = 10 * time.Millisecond
+
+	if _, err := fetchTitle(server.URL); err == nil {
+		t.Fatal("expected an error from a tracker that does not answer")
+	}
+}
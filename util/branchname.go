@@ -0,0 +1,168 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package util
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/juju/errors"
+	"github.com/perrito666/got/git"
+)
+
+// BranchName is the name of a work branch, by default in the form
+// <type>_<target>_<reference>, such as fix_1.2_12345, where the target
+// is the branch the work is meant for and the reference identifies the
+// work, like a bug number or a feature name. Repositories can configure
+// other forms, see Scheme, which can also carry a slug describing the
+// work. Branches without target, such as feature_login, are also valid.
+type BranchName struct {
+	Type      string
+	Target    string
+	Reference string
+	Slug      string
+
+	// scheme is the one the name was parsed with.
+	scheme *Scheme
+}
+
+// String implements fmt.Stringer.
+func (b BranchName) String() string {
+	return b.Format()
+}
+
+// Format returns the git branch name following the scheme b was
+// parsed with or the default one.
+func (b BranchName) Format() string {
+	return b.Scheme().Format(b)
+}
+
+// Scheme returns the scheme b was parsed with or the default one.
+func (b BranchName) Scheme() *Scheme {
+	if b.scheme == nil {
+		return defaultScheme
+	}
+	return b.scheme
+}
+
+// WithScheme returns a copy of b that is formatted with s.
+func (b BranchName) WithScheme(s *Scheme) BranchName {
+	b.scheme = s
+	return b
+}
+
+// Validate checks that git will accept the branch name and that it
+// can be parsed back into b.
+func (b BranchName) Validate() error {
+	if b.Type == "" {
+		return errors.NotValidf("empty branch type")
+	}
+	if strings.ContainsAny(b.Type, optionalSeparators) {
+		return errors.NotValidf("branch type %q with separators", b.Type)
+	}
+	if b.Reference == "" {
+		return errors.NotValidf("empty branch reference")
+	}
+	name := b.Format()
+	parsed, err := b.Scheme().Parse(name, b.Target)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if parsed.Type != b.Type || parsed.Target != b.Target || parsed.Reference != b.Reference || parsed.Slug != b.Slug {
+		return errors.NotValidf("ambiguous branch name %q", name)
+	}
+	return nil
+}
+
+// ParseBranchName returns the BranchName for the given git branch name
+// following the default scheme, see Scheme.Parse.
+func ParseBranchName(name string, targets ...string) (BranchName, error) {
+	b, err := defaultScheme.Parse(name, targets...)
+	return b, errors.Trace(err)
+}
+
+// ResolveBranchName parses the given branch name with the scheme of
+// branchType using the local branches as the known targets.
+func ResolveBranchName(newGit git.CompatibleConstructor, branchType, name string) (BranchName, error) {
+	scheme, err := SchemeFor(newGit, branchType)
+	if err != nil {
+		return BranchName{}, errors.Trace(err)
+	}
+	targets, err := localBranches(newGit)
+	if err != nil {
+		return BranchName{}, errors.Trace(err)
+	}
+	b, err := scheme.Parse(name, targets...)
+	if err != nil {
+		return BranchName{}, errors.Trace(err)
+	}
+	if b.Type != branchType {
+		return BranchName{}, errors.NotValidf("%s branch name %q", branchType, name)
+	}
+	return b, nil
+}
+
+// ValidateRefName checks name against the rules git imposes on branch
+// names, as described in git-check-ref-format(1).
+func ValidateRefName(name string) error {
+	invalid := func(reason string) error {
+		return errors.NotValidf("branch name %q, %s", name, reason)
+	}
+	switch {
+	case name == "":
+		return invalid("it is empty")
+	case name == "@":
+		return invalid("it cannot be @")
+	case strings.HasPrefix(name, "-"):
+		return invalid("it cannot start with -")
+	case strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/"):
+		return invalid("it cannot start or end with /")
+	case strings.HasSuffix(name, "."):
+		return invalid("it cannot end with .")
+	case strings.Contains(name, "//"):
+		return invalid("it cannot contain //")
+	case strings.Contains(name, ".."):
+		return invalid("it cannot contain ..")
+	case strings.Contains(name, "@{"):
+		return invalid("it cannot contain @{")
+	}
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return invalid(fmt.Sprintf("it cannot contain %q", r))
+		}
+	}
+	for _, component := range strings.Split(name, "/") {
+		if strings.HasPrefix(component, ".") {
+			return invalid("its components cannot start with .")
+		}
+		if strings.HasSuffix(component, ".lock") {
+			return invalid("its components cannot end with .lock")
+		}
+	}
+	return nil
+}
+
+// Slugify turns text, such as a bug title, into a short slug suitable
+// for a branch name, ie: "Crash on start-up!" becomes "crash-on-start-up".
+func Slugify(text string) string {
+	const maxLength = 40
+	slug := []rune{}
+	dash := false
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && len(slug) > 0 {
+				slug = append(slug, '-')
+			}
+			slug = append(slug, r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	if len(slug) > maxLength {
+		slug = []rune(strings.TrimRight(string(slug[:maxLength]), "-"))
+	}
+	return string(slug)
+}
@@ -0,0 +1,178 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package util
+
+import "testing"
+
+func TestParseBranchName(t *testing.T) {
+	tests := []struct {
+		name     string
+		targets  []string
+		expected BranchName
+	}{
+		{"fix_1.2_12345", nil, BranchName{Type: "fix", Target: "1.2", Reference: "12345"}},
+		{"fix_master_12345", []string{"master", "1.2"}, BranchName{Type: "fix", Target: "master", Reference: "12345"}},
+		{"feature_master_login_flow", nil, BranchName{Type: "feature", Target: "master", Reference: "login_flow"}},
+		{"feature_master_login_flow", []string{"master"}, BranchName{Type: "feature", Target: "master", Reference: "login_flow"}},
+		{"fix_release_1.2_12345", []string{"master", "release_1.2"}, BranchName{Type: "fix", Target: "release_1.2", Reference: "12345"}},
+		{"fix_release_1.2_12345", []string{"release", "release_1.2"}, BranchName{Type: "fix", Target: "release_1.2", Reference: "12345"}},
+		{"fix_release_1.2_12345", nil, BranchName{Type: "fix", Target: "release", Reference: "1.2_12345"}},
+		{"fix_release/1.2_12345", nil, BranchName{Type: "fix", Target: "release/1.2", Reference: "12345"}},
+		{"fix_team/release_1.2_1", []string{"team/release_1.2"}, BranchName{Type: "fix", Target: "team/release_1.2", Reference: "1"}},
+		{"feature_x", nil, BranchName{Type: "feature", Target: "", Reference: "x"}},
+		{"feature_x", []string{"x"}, BranchName{Type: "feature", Target: "", Reference: "x"}},
+		{"feature_maestro_añadir_ñandú", []string{"maestro"}, BranchName{Type: "feature", Target: "maestro", Reference: "añadir_ñandú"}},
+		{"feature_版本_功能", nil, BranchName{Type: "feature", Target: "版本", Reference: "功能"}},
+	}
+	for _, test := range tests {
+		parsed, err := ParseBranchName(test.name, test.targets...)
+		if err != nil {
+			t.Logf("unexpected error parsing %q: %v", test.name, err)
+			t.Fail()
+			continue
+		}
+		if parsed.WithScheme(nil) != test.expected {
+			t.Logf("expected %#v for %q got %#v", test.expected, test.name, parsed)
+			t.Fail()
+		}
+		if formatted := parsed.Format(); formatted != test.name {
+			t.Logf("expected %q to round trip, got %q", test.name, formatted)
+			t.Fail()
+		}
+	}
+}
+
+func TestParseBranchNameInvalid(t *testing.T) {
+	for _, name := range []string{
+		"",
+		"master",
+		"fix_",
+		"_1.2_123",
+		"fix_1.2..3_123",
+		"fix_1.2_a b",
+		"fix_1.2_x.lock",
+		"fix_1.2_x~1",
+		"fix_1.2_x^",
+		"fix_1.2_x:y",
+		"fix_1.2_x?",
+		"fix_1.2_x*",
+		"fix_1.2_x[1]",
+		"fix_1.2_x\\y",
+		"fix_1.2_@{x}",
+		"fix_1.2_x.",
+		"fix_1.2_x/",
+		"/fix_1.2_x",
+		"fix_1.2//x_1",
+		"fix_1.2/.x_1",
+		"-fix_1.2_x",
+		"fix_1.2_x\t",
+	} {
+		if parsed, err := ParseBranchName(name); err == nil {
+			t.Logf("expected %q to be invalid, got %#v", name, parsed)
+			t.Fail()
+		}
+	}
+}
+
+func TestBranchNameValidate(t *testing.T) {
+	valid := []BranchName{
+		{Type: "fix", Target: "1.2", Reference: "12345"},
+		{Type: "feature", Target: "", Reference: "login"},
+		{Type: "feature", Target: "release/1.2", Reference: "añadir"},
+	}
+	for _, b := range valid {
+		if err := b.Validate(); err != nil {
+			t.Logf("unexpected error validating %#v: %v", b, err)
+			t.Fail()
+		}
+	}
+	invalid := []BranchName{
+		{Type: "", Target: "1.2", Reference: "12345"},
+		{Type: "bug_fix", Target: "1.2", Reference: "12345"},
+		{Type: "fix", Target: "1.2", Reference: ""},
+		{Type: "fix", Target: "1.2", Reference: "a..b"},
+		{Type: "fix", Target: "1.2 ", Reference: "12345"},
+	}
+	for _, b := range invalid {
+		if err := b.Validate(); err == nil {
+			t.Logf("expected %#v to be invalid", b)
+			t.Fail()
+		}
+	}
+}
+
+func TestSchemeParse(t *testing.T) {
+	tests := []struct {
+		template string
+		name     string
+		targets  []string
+		expected BranchName
+	}{
+		{"bugfix/{target}/{id}-{slug}", "bugfix/1.2/12345-crash-on-start", nil,
+			BranchName{Type: "fix", Target: "1.2", Reference: "12345", Slug: "crash-on-start"}},
+		{"bugfix/{target}/{id}-{slug}", "bugfix/1.2/12345", nil,
+			BranchName{Type: "fix", Target: "1.2", Reference: "12345"}},
+		{"bugfix/{target}/{id}-{slug}", "bugfix/12345-crash", nil,
+			BranchName{Type: "fix", Reference: "12345", Slug: "crash"}},
+		{"bugfix/{target}/{id}-{slug}", "bugfix/release/1.2/12345-crash", []string{"release/1.2"},
+			BranchName{Type: "fix", Target: "release/1.2", Reference: "12345", Slug: "crash"}},
+		{"{type}/{target}/{id}", "fix/1.2/12345", nil,
+			BranchName{Type: "fix", Target: "1.2", Reference: "12345"}},
+		{"{type}/{id}", "fix/12345", nil,
+			BranchName{Type: "fix", Reference: "12345"}},
+	}
+	for _, test := range tests {
+		scheme, err := NewScheme(test.template, "fix")
+		if err != nil {
+			t.Logf("unexpected error creating scheme %q: %v", test.template, err)
+			t.Fail()
+			continue
+		}
+		parsed, err := scheme.Parse(test.name, test.targets...)
+		if err != nil {
+			t.Logf("unexpected error parsing %q with %q: %v", test.name, test.template, err)
+			t.Fail()
+			continue
+		}
+		if parsed.WithScheme(nil) != test.expected {
+			t.Logf("expected %#v for %q with %q got %#v", test.expected, test.name, test.template, parsed)
+			t.Fail()
+		}
+		if formatted := scheme.Format(test.expected); formatted != test.name {
+			t.Logf("expected %#v to format as %q with %q, got %q", test.expected, test.name, test.template, formatted)
+			t.Fail()
+		}
+	}
+}
+
+func TestNewSchemeInvalid(t *testing.T) {
+	for _, template := range []string{
+		"",
+		"fix/{target}",
+		"{type/{id}",
+		"{type}/{bug}",
+		"{target}/{id}",
+	} {
+		if _, err := NewScheme(template, ""); err == nil {
+			t.Logf("expected template %q to be invalid", template)
+			t.Fail()
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Crash on start-up!": "crash-on-start-up",
+		"  leading spaces":   "leading-spaces",
+		"Añadir ñandú":       "añadir-ñandú",
+		"!!!":                "",
+		"a very long title that goes well beyond the limit": "a-very-long-title-that-goes-well-beyond",
+	}
+	for text, expected := range tests {
+		if slug := Slugify(text); slug != expected {
+			t.Logf("expected %q for %q got %q", expected, text, slug)
+			t.Fail()
+		}
+	}
+}
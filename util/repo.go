@@ -0,0 +1,143 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/perrito666/got/git"
+)
+
+// DeleteBranch forcibly deletes the given local branch.
+func DeleteBranch(newGit git.CompatibleConstructor, branch string) error {
+	c := newGit(git.SCMDBranch, []string{"-D", branch})
+	return errors.Annotatef(c.Run(), "cannot delete branch %q", branch)
+}
+
+// DeleteWorkBranch deletes the given local branch and, when remote is
+// true, the branch its upstream stands for unless it has changes that
+// are not in target, in which case it is left alone.
+func DeleteWorkBranch(newGit git.CompatibleConstructor, branch, target string, remote bool) error {
+	// the upstream is forgotten along with the local branch.
+	upstream := ""
+	if remote {
+		var err error
+		if upstream, err = Upstream(newGit, branch); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if err := DeleteBranch(newGit, branch); err != nil {
+		return errors.Trace(err)
+	}
+	if !remote {
+		return nil
+	}
+	if upstream == "" {
+		fmt.Printf("%q does not exist in any remote\n", branch)
+		return nil
+	}
+	merged, err := IsMerged(newGit, upstream, target)
+	if err != nil {
+		fmt.Printf("not deleting %q, cannot tell if it is merged into %q: %v\n", upstream, target, err)
+		return nil
+	}
+	if !merged {
+		fmt.Printf("not deleting %q, it has changes that are not in %q\n", upstream, target)
+		return nil
+	}
+	return errors.Trace(DeleteRemoteBranch(newGit, upstream))
+}
+
+// TrackBranch creates a local branch tracking the given remote tracking
+// branch, ie: fix_1.2_4711 for origin/fix_1.2_4711, and switches to it.
+func TrackBranch(newGit git.CompatibleConstructor, remoteRef string) error {
+	c := newGit(git.SCMDCheckout, []string{"--track", remoteRef})
+	return errors.Annotatef(c.Run(), "cannot track branch %q", remoteRef)
+}
+
+// IsMerged returns true if the changes in branch are in target, either
+// because branch was merged or because it was squashed into one commit.
+func IsMerged(newGit git.CompatibleConstructor, branch, target string) (bool, error) {
+	repo := git.NewRepo(newGit)
+	merged, err := repo.IsAncestor(branch, target)
+	if err != nil || merged {
+		return merged, errors.Trace(err)
+	}
+	// a squashed branch is one commit in target with the same changes
+	// the branch has since it started.
+	base, err := repo.MergeBase(target, branch)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	squashed, err := repo.DiffPatchID(base, branch)
+	if err != nil || squashed == "" {
+		return false, errors.Annotatef(err, "cannot squash %q", branch)
+	}
+	landed, err := repo.LogPatchIDs(base + ".." + target)
+	if err != nil {
+		return false, errors.Annotatef(err, "cannot compare %q with %q", branch, target)
+	}
+	return contains(landed, squashed), nil
+}
+
+// Upstream returns the remote tracking branch of the given local branch
+// or, if it has none, the first with the same name in any remote. It
+// returns an empty string if there is none.
+func Upstream(newGit git.CompatibleConstructor, branch string) (string, error) {
+	remotes, err := remoteBranches(newGit)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	locals, err := git.NewRepo(newGit).Branches()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	for _, ref := range locals {
+		if ref.Name != branch {
+			continue
+		}
+		// the upstream might also be a local branch.
+		for _, remote := range remotes {
+			if remote.ref == ref.Upstream {
+				return remote.ref, nil
+			}
+		}
+	}
+	for _, remote := range remotes {
+		if remote.name == branch {
+			return remote.ref, nil
+		}
+	}
+	return "", nil
+}
+
+// splitRemoteRef splits a remote tracking branch, such as
+// origin/fix_1.2_4711, into the remote and the branch in it using the
+// known remote names, ok is false if it belongs to none of them.
+func splitRemoteRef(remotes []string, remoteRef string) (remote, branch string, ok bool) {
+	for _, name := range remotes {
+		// remotes can be nested, as fork and fork/old, the longest wins.
+		if strings.HasPrefix(remoteRef, name+"/") && len(name) > len(remote) {
+			remote, branch, ok = name, remoteRef[len(name)+1:], true
+		}
+	}
+	return remote, branch, ok
+}
+
+// DeleteRemoteBranch deletes the branch the given remote tracking branch
+// stands for, ie: fix_1.2_4711 in origin for origin/fix_1.2_4711.
+func DeleteRemoteBranch(newGit git.CompatibleConstructor, remoteRef string) error {
+	remotes, err := git.NewRepo(newGit).Remotes()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	remote, branch, ok := splitRemoteRef(remotes, remoteRef)
+	if !ok {
+		return errors.NotValidf("remote branch %q", remoteRef)
+	}
+	c := newGit("push", []string{remote, "--delete", branch})
+	return errors.Annotatef(c.Run(), "cannot delete remote branch %q", remoteRef)
+}
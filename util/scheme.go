@@ -0,0 +1,312 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package util
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/juju/errors"
+	"github.com/perrito666/got/config"
+	"github.com/perrito666/got/git"
+)
+
+// DefaultTemplate is the branch naming template used unless the
+// repository configures another one.
+const DefaultTemplate = "{type}_{target}_{id}"
+
+// The placeholders a branch naming template can contain.
+const (
+	typeField   = "type"
+	targetField = "target"
+	idField     = "id"
+	slugField   = "slug"
+)
+
+// optionalSeparators are the characters a literal next to an optional
+// field must be made of to be dropped along with the field.
+const optionalSeparators = "_-/."
+
+var defaultScheme = mustScheme(DefaultTemplate, "")
+
+// token is either a literal part of a template or a placeholder.
+type token struct {
+	field   string
+	literal string
+}
+
+// Scheme names work branches according to a template such as
+// "bugfix/{target}/{id}-{slug}". The target and slug are optional,
+// when empty they are left out along with the separator next to them.
+type Scheme struct {
+	template   string
+	branchType string
+	// variants holds the template with and without its optional fields,
+	// the most complete first.
+	variants []variant
+}
+
+type variant struct {
+	hasTarget bool
+	hasSlug   bool
+	tokens    []token
+}
+
+// NewScheme returns the Scheme for template, branchType is the type of
+// the branches named by it when the template has no {type} placeholder.
+func NewScheme(template, branchType string) (*Scheme, error) {
+	tokens, err := tokenize(template)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	s := &Scheme{template: template, branchType: branchType}
+	if !hasField(tokens, idField) {
+		return nil, errors.NotValidf("branch template %q without {id}", template)
+	}
+	if !hasField(tokens, typeField) && branchType == "" {
+		return nil, errors.NotValidf("branch template %q without {type}", template)
+	}
+	for _, dropTarget := range []bool{false, true} {
+		for _, dropSlug := range []bool{false, true} {
+			if (dropTarget && !hasField(tokens, targetField)) || (dropSlug && !hasField(tokens, slugField)) {
+				continue
+			}
+			v := tokens
+			if dropTarget {
+				v = withoutField(v, targetField)
+			}
+			if dropSlug {
+				v = withoutField(v, slugField)
+			}
+			s.variants = append(s.variants, variant{
+				hasTarget: hasField(v, targetField),
+				hasSlug:   hasField(v, slugField),
+				tokens:    v,
+			})
+		}
+	}
+	return s, nil
+}
+
+func mustScheme(template, branchType string) *Scheme {
+	s, err := NewScheme(template, branchType)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// SchemeFor returns the Scheme configured in the repository for the
+// given type of work branch or the default one.
+func SchemeFor(newGit git.CompatibleConstructor, branchType string) (*Scheme, error) {
+	template, err := config.BranchTemplate(newGit, branchType)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot determine the branch naming scheme")
+	}
+	if template == "" {
+		template = DefaultTemplate
+	}
+	s, err := NewScheme(template, branchType)
+	return s, errors.Trace(err)
+}
+
+// Template returns the template of the scheme.
+func (s *Scheme) Template() string {
+	return s.template
+}
+
+// UsesSlug returns true if branch names carry a slug.
+func (s *Scheme) UsesSlug() bool {
+	return s.variants[0].hasSlug
+}
+
+// Format returns the name of the git branch for b.
+func (s *Scheme) Format(b BranchName) string {
+	tokens := s.variant(b.Target != "", b.Slug != "").tokens
+	values := map[string]string{
+		typeField:   b.Type,
+		targetField: b.Target,
+		idField:     b.Reference,
+		slugField:   b.Slug,
+	}
+	name := ""
+	for _, t := range tokens {
+		if t.field == "" {
+			name += t.literal
+			continue
+		}
+		name += values[t.field]
+	}
+	return name
+}
+
+// variant returns the variant that best fits the fields available,
+// the first one without the missing fields if none fits exactly.
+func (s *Scheme) variant(withTarget, withSlug bool) variant {
+	for _, v := range s.variants {
+		if v.hasTarget == withTarget && v.hasSlug == withSlug {
+			return v
+		}
+	}
+	for _, v := range s.variants {
+		if (withTarget || !v.hasTarget) && (withSlug || !v.hasSlug) {
+			return v
+		}
+	}
+	return s.variants[0]
+}
+
+// Parse returns the BranchName for the given git branch name. When more
+// than one split of the name fits the template the known targets are
+// used to choose, the longest one that fits wins, if none does the target
+// is assumed to end at its first separator.
+func (s *Scheme) Parse(name string, targets ...string) (BranchName, error) {
+	if err := ValidateRefName(name); err != nil {
+		return BranchName{}, errors.Trace(err)
+	}
+	known := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		known[target] = true
+	}
+
+	var first, best *BranchName
+	for _, v := range s.variants {
+		matchTokens(v.tokens, name, map[string]string{}, func(values map[string]string) {
+			b := BranchName{
+				Type:      values[typeField],
+				Target:    values[targetField],
+				Reference: values[idField],
+				Slug:      values[slugField],
+				scheme:    s,
+			}
+			if b.Type == "" {
+				b.Type = s.branchType
+			}
+			// separators belong to the template, not to the type.
+			if strings.ContainsAny(b.Type, optionalSeparators) {
+				return
+			}
+			if first == nil {
+				first = &b
+			}
+			if known[b.Target] && (best == nil || len(b.Target) > len(best.Target)) {
+				best = &b
+			}
+		})
+	}
+	if best != nil {
+		return *best, nil
+	}
+	if first != nil {
+		return *first, nil
+	}
+	return BranchName{}, errors.NotValidf("work branch name %q for template %q", name, s.template)
+}
+
+// matchTokens calls found with the values of every way in which name
+// fits tokens, shortest values first.
+func matchTokens(tokens []token, name string, values map[string]string, found func(map[string]string)) {
+	if len(tokens) == 0 {
+		if name == "" {
+			found(values)
+		}
+		return
+	}
+	t := tokens[0]
+	if t.field == "" {
+		if strings.HasPrefix(name, t.literal) {
+			matchTokens(tokens[1:], name[len(t.literal):], values, found)
+		}
+		return
+	}
+	try := func(value string) {
+		if value == "" {
+			return
+		}
+		if previous, ok := values[t.field]; ok && previous != value {
+			return
+		}
+		next := make(map[string]string, len(values)+1)
+		for k, v := range values {
+			next[k] = v
+		}
+		next[t.field] = value
+		matchTokens(tokens[1:], name[len(value):], next, found)
+	}
+	if len(tokens) == 1 {
+		try(name)
+		return
+	}
+	for i := 1; i <= len(name); i++ {
+		if i < len(name) && !utf8.RuneStart(name[i]) {
+			continue
+		}
+		if next := tokens[1]; next.field == "" && !strings.HasPrefix(name[i:], next.literal) {
+			continue
+		}
+		try(name[:i])
+	}
+}
+
+// tokenize splits a template into literals and placeholders.
+func tokenize(template string) ([]token, error) {
+	tokens := []token{}
+	rest := template
+	for rest != "" {
+		open := strings.Index(rest, "{")
+		if open < 0 {
+			tokens = append(tokens, token{literal: rest})
+			break
+		}
+		if open > 0 {
+			tokens = append(tokens, token{literal: rest[:open]})
+		}
+		end := strings.Index(rest, "}")
+		if end < open {
+			return nil, errors.NotValidf("branch template %q, unclosed placeholder", template)
+		}
+		field := rest[open+1 : end]
+		switch field {
+		case typeField, targetField, idField, slugField:
+		default:
+			return nil, errors.NotValidf("branch template %q, unknown placeholder {%s}", template, field)
+		}
+		tokens = append(tokens, token{field: field})
+		rest = rest[end+1:]
+	}
+	return tokens, nil
+}
+
+func hasField(tokens []token, field string) bool {
+	for _, t := range tokens {
+		if t.field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutField removes field from tokens along with the separator that
+// follows it, or if none the one that precedes it.
+func withoutField(tokens []token, field string) []token {
+	result := []token{}
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i].field != field {
+			result = append(result, tokens[i])
+			continue
+		}
+		if i+1 < len(tokens) && isSeparatorLiteral(tokens[i+1]) {
+			i++
+			continue
+		}
+		if n := len(result); n > 0 && isSeparatorLiteral(result[n-1]) {
+			result = result[:n-1]
+		}
+	}
+	return result
+}
+
+func isSeparatorLiteral(t token) bool {
+	return t.field == "" && strings.Trim(t.literal, optionalSeparators) == ""
+}
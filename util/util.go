@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/juju/errors"
+	"github.com/perrito666/got/config"
 	"github.com/perrito666/got/git"
 	"github.com/perrito666/got/interfaces"
 )
@@ -19,98 +20,474 @@ const (
 	FixType = "fix"
 )
 
-// PrintList will print a list of items.
-func PrintList(newGit git.CompatibleConstructor, branchType string, short bool) error {
-	fixes, err := ListBranches(newGit, branchType)
+// Location tells where a work branch exists.
+type Location int
+
+const (
+	// Local branches exist in the repository.
+	Local Location = 1 << iota
+	// Remote branches exist as remote tracking branches.
+	Remote
+	// Both local branches that also exist in a remote.
+	Both = Local | Remote
+)
+
+// String implements fmt.Stringer.
+func (l Location) String() string {
+	switch l {
+	case Local:
+		return "local"
+	case Remote:
+		return "remote"
+	case Both:
+		return "local and remote"
+	}
+	return "unknown"
+}
+
+// WorkBranch is a work branch found in the repository.
+type WorkBranch struct {
+	BranchName
+	Location Location
+	// RemoteRef is the remote tracking branch, ie: origin/fix_1.2_4711,
+	// if the branch exists in a remote.
+	RemoteRef string
+}
+
+// Ref returns the ref that points to the branch, the local one
+// if there is one.
+func (w WorkBranch) Ref() string {
+	if w.Location&Local == 0 {
+		return w.RemoteRef
+	}
+	return w.Format()
+}
+
+// Reportf prints a message about a change made to the repository, under
+// a dry run nothing is printed since the change was not made.
+func Reportf(format string, args ...interface{}) {
+	if git.DryRun {
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
+// PrintList will print a list of items, when remotes is true the
+// branches that only exist in remotes are included.
+func PrintList(newGit git.CompatibleConstructor, branchType string, short, remotes bool) error {
+	fixes, err := ListBranches(newGit, branchType, remotes)
 	if err != nil {
 		return errors.Trace(err)
 	}
 
-	for branch, targets := range fixes {
-		fmt.Println(branch)
+	for reference, branches := range fixes {
+		fmt.Println(reference)
 		if short {
 			continue
 		}
-		for _, target := range targets {
+		for _, branch := range branches {
+			target := branch.Target
+			if target == "" {
+				target = "(no target)"
+			}
+			if remotes {
+				target = fmt.Sprintf("%s (%s)", target, branch.Location)
+			}
 			fmt.Println(fmt.Sprintf("  - %s", target))
 		}
 	}
 	return nil
 }
 
-// ListBranches returns a map containing all open bug fixes and a list
-// of the target branches being develped for each.
-func ListBranches(newGit git.CompatibleConstructor, branchType string) (map[string][]string, error) {
-	c := newGit("branch", []string{"--list", "--no-color"})
-	cmd, err := c.Git()
+// localBranches returns the names of all the local branches.
+func localBranches(newGit git.CompatibleConstructor) ([]string, error) {
+	refs, err := git.NewRepo(newGit).Branches()
 	if err != nil {
-		return nil, errors.Annotate(err, "cannot create git command caller")
+		return nil, errors.Trace(err)
 	}
+	return refNames(refs), nil
+}
 
-	out, err := cmd.Output()
+// remoteBranch is a remote tracking branch, such as origin/fix_1.2_1.
+type remoteBranch struct {
+	// ref is the short name of the remote tracking branch.
+	ref string
+	// name is the name of the branch in the remote, ie: fix_1.2_1.
+	name string
+}
+
+// remoteBranches returns the remote tracking branches of the configured
+// remotes sorted by name, omitting symbolic ones like origin/HEAD.
+func remoteBranches(newGit git.CompatibleConstructor) ([]remoteBranch, error) {
+	repo := git.NewRepo(newGit)
+	remotes, err := repo.Remotes()
 	if err != nil {
-		return nil, errors.Annotatef(err, "calling git %q failed: %v", c.SubCommand, out)
+		return nil, errors.Trace(err)
 	}
+	refs, err := repo.RemoteBranches()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	branches := []remoteBranch{}
+	for _, ref := range refs {
+		if _, name, ok := splitRemoteRef(remotes, ref.Name); ok {
+			branches = append(branches, remoteBranch{ref: ref.Name, name: name})
+		}
+	}
+	return branches, nil
+}
 
-	branchList := string(out)
+func refNames(refs []git.Ref) []string {
+	names := make([]string, len(refs))
+	for i, ref := range refs {
+		names[i] = ref.Name
+	}
+	return names
+}
 
-	branches := strings.Split(branchList, "\n")
-	foundBranches := make(map[string][]string)
-	for _, branch := range branches {
-		// current might be marked with an asterisk.
-		if strings.HasPrefix(strings.TrimSpace(branch), "*") {
-			branch = branch[1:]
+// ListBranches returns a map containing all open work branches of the
+// given type keyed by their reference (ie: the bug being fixed), there
+// is one branch for each target the work is being develped for.
+// When remotes is true the remote tracking branches are included too,
+// merged with the local branch of the same name if there is one.
+func ListBranches(newGit git.CompatibleConstructor, branchType string, remotes bool) (map[string][]WorkBranch, error) {
+	scheme, err := SchemeFor(newGit, branchType)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	locals, err := git.NewRepo(newGit).Branches()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	branches := refNames(locals)
+	targets := append([]string{}, branches...)
+	remoteRefs := []remoteBranch{}
+	if remotes {
+		if remoteRefs, err = remoteBranches(newGit); err != nil {
+			return nil, errors.Trace(err)
 		}
+		for _, remote := range remoteRefs {
+			targets = append(targets, remote.name)
+		}
+	}
+	// the same branch can be in several remotes, the one the local
+	// branch tracks is preferred over the first.
+	upstreams := map[string]string{}
+	for _, local := range locals {
+		upstreams[local.Name] = local.Upstream
+	}
 
-		if strings.HasPrefix(strings.TrimSpace(branch), fmt.Sprintf("%s_", branchType)) {
-			parts := strings.SplitN(branch, "_", 3)
-			// this is not one of ours.
-			if len(parts) != 3 {
-				continue
-			}
-			target := parts[1]
-			branchName := parts[2]
-			targets := foundBranches[branchName]
-			targets = append(targets, target)
-			foundBranches[branchName] = targets
+	found := map[string]*WorkBranch{}
+	names := []string{}
+	add := func(name string, location Location, remoteRef string) {
+		parsed, err := scheme.Parse(name, targets...)
+		// this is not one of ours.
+		if err != nil || parsed.Type != branchType {
+			return
+		}
+		branch, ok := found[name]
+		if !ok {
+			branch = &WorkBranch{BranchName: parsed}
+			found[name] = branch
+			names = append(names, name)
 		}
+		branch.Location |= location
+		if branch.RemoteRef == "" || remoteRef == upstreams[name] {
+			branch.RemoteRef = remoteRef
+		}
+	}
+	for _, branch := range branches {
+		add(branch, Local, "")
+	}
+	for _, remote := range remoteRefs {
+		add(remote.name, Remote, remote.ref)
+	}
+
+	foundBranches := make(map[string][]WorkBranch)
+	for _, name := range names {
+		branch := found[name]
+		foundBranches[branch.Reference] = append(foundBranches[branch.Reference], *branch)
 	}
 	return foundBranches, nil
 }
 
-// CraftBranchName returns the name of a branch using the params.
-func CraftBranchName(branchType, reference, target string) string {
-	return fmt.Sprintf("%s_%s_%s", branchType, target, reference)
+// ListMaintenanceBranches returns the local branches that are not work
+// branches of any known type, these are the candidates to be targets.
+func ListMaintenanceBranches(newGit git.CompatibleConstructor) ([]string, error) {
+	schemes, err := workSchemes(newGit)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	branches, err := localBranches(newGit)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	maintenance := []string{}
+	for _, branch := range branches {
+		if isWorkBranch(schemes, branch) {
+			continue
+		}
+		maintenance = append(maintenance, branch)
+	}
+	return maintenance, nil
 }
 
-// Picker presents a choice between branches of a type
-func Picker(branchType string, newGit git.CompatibleConstructor, ui interfaces.UI) (string, error) {
-	fixes, err := ListBranches(newGit, branchType)
+// WorkTypes returns the built in types of work branch followed by the
+// ones declared in the repository configuration.
+func WorkTypes(newGit git.CompatibleConstructor) ([]string, error) {
+	declared, err := config.WorkTypes(newGit)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot determine the work branch types")
+	}
+	types := []string{FeatureType, FixType}
+	for _, branchType := range declared {
+		if !contains(types, branchType) {
+			types = append(types, branchType)
+		}
+	}
+	return types, nil
+}
+
+// workSchemes returns the naming schemes of all the known work types.
+func workSchemes(newGit git.CompatibleConstructor) (map[string]*Scheme, error) {
+	types, err := WorkTypes(newGit)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	schemes := make(map[string]*Scheme, len(types))
+	for _, branchType := range types {
+		scheme, err := SchemeFor(newGit, branchType)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		schemes[branchType] = scheme
+	}
+	return schemes, nil
+}
+
+func isWorkBranch(schemes map[string]*Scheme, branch string) bool {
+	for branchType, scheme := range schemes {
+		parsed, err := scheme.Parse(branch)
+		if err == nil && parsed.Type == branchType {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, item string) bool {
+	for _, v := range list {
+		if v == item {
+			return true
+		}
+	}
+	return false
+}
+
+// PickTarget presents a choice between the local maintenance branches
+// and the remote ones that have no local counterpart. It returns the
+// target name and the ref the new branch should start from, which
+// differ for remote branches (ie: 1.2 and origin/1.2).
+func PickTarget(newGit git.CompatibleConstructor, ui interfaces.UI) (string, string, error) {
+	targets, err := ListMaintenanceBranches(newGit)
+	if err != nil {
+		return "", "", errors.Trace(err)
+	}
+	startPoints := append([]string{}, targets...)
+	schemes, err := workSchemes(newGit)
+	if err != nil {
+		return "", "", errors.Trace(err)
+	}
+	remotes, err := remoteBranches(newGit)
+	if err != nil {
+		return "", "", errors.Trace(err)
+	}
+	for _, remote := range remotes {
+		if isWorkBranch(schemes, remote.name) || contains(targets, remote.name) {
+			continue
+		}
+		targets = append(targets, remote.name)
+		startPoints = append(startPoints, remote.ref)
+	}
+	if len(startPoints) == 0 {
+		return "", "", errors.NotFoundf("target branches")
+	}
+	chosen, err := ui.ChoiceMenu(interfaces.NamedItems(startPoints), true, nil)
+	if err != nil {
+		return "", "", errors.Annotate(err, "interactive target branch choice failed")
+	}
+	if len(chosen) == 0 {
+		return "", "", nil
+	}
+	return targets[chosen[0]], startPoints[chosen[0]], nil
+}
+
+// DefaultBase returns the configured default base branch for the
+// given branch type or an empty string if there is none.
+func DefaultBase(newGit git.CompatibleConstructor, branchType string) (string, error) {
+	base, err := git.NewRepo(newGit).ConfigGet(defaultBaseKey(branchType))
+	if err != nil {
+		return "", errors.Annotate(err, "cannot determine default base branch")
+	}
+	return base, nil
+}
+
+// SetDefaultBase stores base as the default base branch for the given
+// branch type.
+func SetDefaultBase(newGit git.CompatibleConstructor, branchType, base string) error {
+	if err := git.NewRepo(newGit).ConfigSet(defaultBaseKey(branchType), base); err != nil {
+		return errors.Annotate(err, "cannot store default base branch")
+	}
+	return nil
+}
+
+func defaultBaseKey(branchType string) string {
+	return fmt.Sprintf("got.%s.default", branchType)
+}
+
+// PickBase presents a choice between the maintenance branches, any
+// branch in exclude will not be offered.
+func PickBase(newGit git.CompatibleConstructor, ui interfaces.UI, exclude ...string) (string, error) {
+	all, err := ListMaintenanceBranches(newGit)
 	if err != nil {
 		return "", errors.Trace(err)
 	}
-	choices := []string{}
-	index := []string{}
-	for bug, targets := range fixes {
-		for _, target := range targets {
-			choices = append(choices, fmt.Sprintf("%q (%s)", bug, target))
-			index = append(index, CraftBranchName(branchType, bug, target))
+	branches := []string{}
+	for _, branch := range all {
+		if !contains(exclude, branch) {
+			branches = append(branches, branch)
 		}
 	}
-	chosen, err := ui.ChoiceMenu(choices, true, -1)
+	if len(branches) == 0 {
+		return "", errors.NotFoundf("maintenance branches")
+	}
+	chosen, err := ui.ChoiceMenu(interfaces.NamedItems(branches), true, nil)
 	if err != nil {
-		return "", errors.Annotate(err, "interactive branch choice failed")
+		return "", errors.Annotate(err, "interactive base branch choice failed")
 	}
 	if len(chosen) == 0 {
 		return "", nil
 	}
-	return index[chosen[0]], nil
+	return branches[chosen[0]], nil
+}
+
+// SanitizeName replaces the characters that are not suitable for a branch
+// reference, such as a feature name, with underscores.
+func SanitizeName(name string) string {
+	for _, invalidChar := range []string{",", "-", ".", " "} {
+		name = strings.Replace(name, invalidChar, "_", -1)
+	}
+	return name
+}
+
+// Picker presents a choice between branches of a type, if reference
+// is not empty only the branches for it are offered, when remotes is
+// true so are the ones that only exist in remotes. Each branch is
+// described by its last commit and how far it is from its target.
+// It returns nil if none was chosen.
+func Picker(branchType, reference string, remotes bool, newGit git.CompatibleConstructor, ui interfaces.UI) (*WorkBranch, error) {
+	fixes, err := ListBranches(newGit, branchType, remotes)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	choices := []interfaces.Item{}
+	index := []WorkBranch{}
+	for bug, branches := range fixes {
+		if reference != "" && bug != reference {
+			continue
+		}
+		for _, branch := range branches {
+			name := fmt.Sprintf("%q (%s)", bug, branch.Target)
+			if remotes {
+				name = fmt.Sprintf("%s [%s]", name, branch.Location)
+			}
+			choices = append(choices, interfaces.Item{
+				Name:        name,
+				Description: DescribeBranch(newGit, branch.Ref(), branch.Target),
+			})
+			index = append(index, branch)
+		}
+	}
+	current, err := git.NewRepo(newGit).CurrentBranch()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	preselected := []int{}
+	for i, branch := range index {
+		if branch.Location&Local != 0 && branch.Format() == current {
+			preselected = append(preselected, i)
+		}
+	}
+	chosen, err := ui.ChoiceMenu(choices, true, preselected)
+	if err != nil {
+		return nil, errors.Annotate(err, "interactive branch choice failed")
+	}
+	if len(chosen) == 0 {
+		return nil, nil
+	}
+	return &index[chosen[0]], nil
 }
 
-// NewBranch creates a new branch from the given target.
-func NewBranch(newGit git.CompatibleConstructor, branchType, target, name string) (string, error) {
-	branch := CraftBranchName(branchType, name, target)
-	c := newGit("checkout", []string{"-b", branch, target})
+// PickCommit presents a choice between the given commits showing their
+// subject and hash, it returns nil if none was chosen.
+func PickCommit(commits []git.Commit, ui interfaces.UI) (*git.Commit, error) {
+	if len(commits) == 0 {
+		return nil, errors.NotFoundf("commits")
+	}
+	choices := make([]interfaces.Item, len(commits))
+	for i, commit := range commits {
+		choices[i] = interfaces.Item{
+			Name:        commit.Subject,
+			Description: commit.ShortHash(),
+		}
+	}
+	chosen, err := ui.ChoiceMenu(choices, true, nil)
+	if err != nil {
+		return nil, errors.Annotate(err, "interactive commit choice failed")
+	}
+	if len(chosen) == 0 {
+		return nil, nil
+	}
+	return &commits[chosen[0]], nil
+}
+
+// DescribeBranch returns a summary of the last commit of branch and how
+// many commits it is ahead and behind target, ie:
+// "2 ahead, 5 behind, 3 days ago: Fix the thing". The parts that cannot
+// be determined are left out.
+func DescribeBranch(newGit git.CompatibleConstructor, branch, target string) string {
+	repo := git.NewRepo(newGit)
+	parts := []string{}
+	if ahead, behind, err := repo.AheadBehind(target, branch); err == nil {
+		parts = append(parts, fmt.Sprintf("%d ahead, %d behind", ahead, behind))
+	}
+	last, err := repo.LastCommit(branch)
+	if err != nil {
+		return strings.Join(parts, ", ")
+	}
+	parts = append(parts, fmt.Sprintf("%s: %s", last.Age, last.Subject))
+	return strings.Join(parts, ", ")
+}
+
+// NewBranch creates the branch b from its target, it is named after the
+// scheme configured for its type.
+func NewBranch(newGit git.CompatibleConstructor, b BranchName) (string, error) {
+	return NewBranchFrom(newGit, b, b.Target)
+}
+
+// NewBranchFrom creates the branch b starting at startPoint, which is
+// useful when the target only exists in a remote.
+func NewBranchFrom(newGit git.CompatibleConstructor, b BranchName, startPoint string) (string, error) {
+	scheme, err := SchemeFor(newGit, b.Type)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	b = b.WithScheme(scheme)
+	if err := b.Validate(); err != nil {
+		return "", errors.Trace(err)
+	}
+	branch := b.Format()
+	c := newGit("checkout", []string{"-b", branch, startPoint})
 	cmd, err := c.Git()
 	if err != nil {
 		return "", errors.Annotate(err, "cannot create git command caller")
@@ -118,7 +495,7 @@ func NewBranch(newGit git.CompatibleConstructor, branchType, target, name string
 
 	out, err := cmd.Output()
 	if err != nil {
-		return "", errors.Annotatef(err, "calling git %q %v failed: %v", c.SubCommand(), c.Args(), out)
+		return "", errors.Annotatef(err, "cannot create branch %q", branch)
 	}
 	printable := string(out)
 	if printable != "" {
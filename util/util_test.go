@@ -0,0 +1,98 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package util
+
+import (
+	"testing"
+
+	gtesting "github.com/perrito666/got/testing"
+)
+
+func TestSanitizeName(t *testing.T) {
+	tests := map[string]string{
+		"login_flow":     "login_flow",
+		"login-flow":     "login_flow",
+		"login flow":     "login_flow",
+		"login.flow, v2": "login_flow__v2",
+		"already_sane_1": "already_sane_1",
+	}
+	for name, expected := range tests {
+		if sanitized := SanitizeName(name); sanitized != expected {
+			t.Logf("expected %q for %q got %q", expected, name, sanitized)
+			t.Fail()
+		}
+	}
+}
+
+func TestWorkBranchRef(t *testing.T) {
+	name := BranchName{Type: FixType, Target: "1.2", Reference: "4711"}
+	tests := []struct {
+		branch   WorkBranch
+		expected string
+	}{
+		{WorkBranch{BranchName: name, Location: Local}, "fix_1.2_4711"},
+		{WorkBranch{BranchName: name, Location: Both, RemoteRef: "origin/fix_1.2_4711"}, "fix_1.2_4711"},
+		{WorkBranch{BranchName: name, Location: Remote, RemoteRef: "origin/fix_1.2_4711"}, "origin/fix_1.2_4711"},
+	}
+	for _, test := range tests {
+		if ref := test.branch.Ref(); ref != test.expected {
+			t.Logf("expected %q for %s branch got %q", test.expected, test.branch.Location, ref)
+			t.Fail()
+		}
+	}
+}
+
+func TestListBranchesWithRemotes(t *testing.T) {
+	script := &gtesting.ScriptedGit{Responses: map[string]gtesting.Response{
+		"remote": {Output: "fork\norigin\n"},
+	}}
+	// fork comes first but fix_1.2_1 tracks origin.
+	script.AddBranches(
+		[]gtesting.Branch{{Name: "1.2"}, {Name: "fix_1.2_1", Upstream: "origin/fix_1.2_1", Current: true}},
+		[]string{"fork/fix_1.2_1", "origin/HEAD", "origin/fix_1.2_1", "origin/fix_1.2_2"},
+	)
+	branches, err := ListBranches(script.New, FixType, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]WorkBranch{
+		"1": {Location: Both, RemoteRef: "origin/fix_1.2_1"},
+		"2": {Location: Remote, RemoteRef: "origin/fix_1.2_2"},
+	}
+	if len(branches) != len(expected) {
+		t.Fatalf("expected %d bugs got %#v", len(expected), branches)
+	}
+	for bug, want := range expected {
+		got := branches[bug]
+		if len(got) != 1 || got[0].Target != "1.2" || got[0].Location != want.Location || got[0].RemoteRef != want.RemoteRef {
+			t.Logf("expected one %s branch for bug %q tracking %q, got %#v", want.Location, bug, want.RemoteRef, got)
+			t.Fail()
+		}
+	}
+}
+
+func TestUpstream(t *testing.T) {
+	script := &gtesting.ScriptedGit{Responses: map[string]gtesting.Response{
+		"remote": {Output: "fork\nfork/old\norigin\n"},
+	}}
+	script.AddBranches(
+		[]gtesting.Branch{{Name: "fix_1.2_1", Upstream: "origin/fix_1.2_1", Current: true}, {Name: "fix_1.2_3"}},
+		[]string{"fork/fix_1.2_1", "fork/old/fix_1.2_2", "origin/fix_1.2_1", "origin/old/fix_1.2_3"},
+	)
+	tests := map[string]string{
+		// the configured upstream wins over the first remote.
+		"fix_1.2_1": "origin/fix_1.2_1",
+		"fix_1.2_2": "fork/old/fix_1.2_2",
+		// old/fix_1.2_3 is another branch in origin.
+		"fix_1.2_3":     "",
+		"old/fix_1.2_3": "origin/old/fix_1.2_3",
+	}
+	for branch, expected := range tests {
+		upstream, err := Upstream(script.New, branch)
+		if err != nil || upstream != expected {
+			t.Logf("expected upstream %q for %q got %q (%v)", expected, branch, upstream, err)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package clean
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/juju/errors"
+	"github.com/perrito666/got/git"
+	"github.com/perrito666/got/interfaces"
+	"github.com/perrito666/got/util"
+)
+
+// Command holds the configuration and methods required for the clean
+// sub-command of any type of work branch.
+type Command struct {
+	Type         string
+	DeleteRemote bool
+	UI           interfaces.UI
+	NewGit       git.CompatibleConstructor
+}
+
+// Handle is the entry point for the Clean sub-command, it offers to
+// delete the work branches already merged into their targets.
+func (c *Command) Handle() error {
+	merged, err := c.Merged()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(merged) == 0 {
+		fmt.Printf("there are no merged %s branches\n", c.Type)
+		return nil
+	}
+
+	choices := make([]interfaces.Item, len(merged))
+	preselected := make([]int, len(merged))
+	for i, branch := range merged {
+		choices[i] = interfaces.Item{
+			Name:        branch.Format(),
+			Description: fmt.Sprintf("merged into %s", branch.Target),
+		}
+		preselected[i] = i
+	}
+	chosen, err := c.UI.ChoiceMenu(choices, false, preselected)
+	if err != nil {
+		return errors.Annotate(err, "interactive branch choice failed")
+	}
+
+	for _, i := range chosen {
+		if err := util.DeleteWorkBranch(c.NewGit, merged[i].Format(), merged[i].Target, c.DeleteRemote); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// Merged returns the work branches that are merged into their targets,
+// including the ones squashed into a single commit, sorted by name.
+// The current branch is left out as it cannot be deleted, and so are
+// the branches without commits of their own.
+func (c *Command) Merged() ([]util.BranchName, error) {
+	all, err := util.ListBranches(c.NewGit, c.Type, false)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	repo := git.NewRepo(c.NewGit)
+	current, err := repo.CurrentBranch()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	merged := []util.BranchName{}
+	for _, branches := range all {
+		for _, branch := range branches {
+			name := branch.Format()
+			if branch.Target == "" || name == current {
+				continue
+			}
+			own, err := hasOwnCommits(repo, name, branch.Target)
+			if err != nil {
+				// most likely the target does not exist locally.
+				fmt.Printf("skipping %q: %v\n", name, err)
+				continue
+			}
+			if !own {
+				continue
+			}
+			isMerged, err := util.IsMerged(c.NewGit, name, branch.Target)
+			if err != nil {
+				fmt.Printf("skipping %q: %v\n", name, err)
+				continue
+			}
+			if isMerged {
+				merged = append(merged, branch.BranchName)
+			}
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Format() < merged[j].Format()
+	})
+	return merged, nil
+}
+
+// hasOwnCommits returns false if branch points to a commit in the first
+// parent history of target, as a branch just created from it does.
+func hasOwnCommits(repo *git.Repo, branch, target string) (bool, error) {
+	tip, err := repo.Log("-1", branch)
+	if err != nil || len(tip) == 0 {
+		return false, errors.Trace(err)
+	}
+	// target back to the parents of the tip, where the tip would be.
+	line, err := repo.Log("--first-parent", target, "^"+branch+"^@")
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	for _, commit := range line {
+		if commit.Hash == tip[0].Hash {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package clean
+
+import (
+	"testing"
+
+	gtesting "github.com/perrito666/got/testing"
+	"github.com/perrito666/got/util"
+)
+
+func TestHandleNothingToClean(t *testing.T) {
+	ui := &gtesting.FakeUI{}
+	c := Command{
+		Type:   util.FixType,
+		UI:     ui,
+		NewGit: gtesting.New,
+	}
+	if err := c.Handle(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ui.Currents) != 0 {
+		t.Fatalf("expected no menu to be shown, got %d", len(ui.Currents))
+	}
+}
+
+// cleanScript is a repository on 1.2 where fix_1.2_1 was merged,
+// fix_1.2_2 squashed, fix_1.2_3 is in progress and fix_1.2_4 was just
+// created, only fix_1.2_1 is in origin.
+func cleanScript() *gtesting.ScriptedGit {
+	script := &gtesting.ScriptedGit{Responses: map[string]gtesting.Response{
+		"rev-parse --abbrev-ref HEAD": {Output: "1.2\n"},
+		"remote":                      {Output: "origin\n"},
+		"log -1 fix_1.2_1":            {Output: "a\x00x\x00Fix 1\n"},
+		"log -1 fix_1.2_2":            {Output: "b\x00x\x00Fix 2\n"},
+		"log -1 fix_1.2_3":            {Output: "c\x00x\x00Fix 3\n"},
+		"log -1 fix_1.2_4":            {Output: "d\x00x\x00Release\n"},
+		"log --first-parent 1.2":      {Output: "m\x00a d\x00Merge fix_1.2_1\nd\x00x\x00Release\n"},
+		// fix_1.2_1 was merged, the rest are not in 1.2 as they are.
+		"merge-base --is-ancestor fix_1.2_2 1.2": {ExitCode: 1},
+		"merge-base --is-ancestor fix_1.2_3 1.2": {ExitCode: 1},
+		"merge-base 1.2":                         {Output: "x\n"},
+		"diff x fix_1.2_2":                       {Output: "patch2"},
+		"diff x fix_1.2_3":                       {Output: "patch3"},
+		"patch-id patch2":                        {Output: "two 0000\n"},
+		"patch-id patch3":                        {Output: "three 0000\n"},
+		"log -p x..1.2":                          {Output: "landed"},
+		"patch-id landed":                        {Output: "two 0000\nrelease 0000\n"},
+	}}
+	script.AddBranches([]gtesting.Branch{
+		{Name: "1.2", Current: true},
+		{Name: "fix_1.2_1", Upstream: "origin/fix_1.2_1"},
+		{Name: "fix_1.2_2"},
+		{Name: "fix_1.2_3"},
+		{Name: "fix_1.2_4"},
+	}, []string{"origin/fix_1.2_1"})
+	return script
+}
+
+func TestMerged(t *testing.T) {
+	c := Command{
+		Type:   util.FixType,
+		NewGit: cleanScript().New,
+	}
+	merged, err := c.Merged()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	names := []string{}
+	for _, branch := range merged {
+		names = append(names, branch.Format())
+	}
+	if len(names) != 2 || names[0] != "fix_1.2_1" || names[1] != "fix_1.2_2" {
+		t.Fatalf("expected fix_1.2_1 and the squashed fix_1.2_2 got %q", names)
+	}
+}
+
+func TestHandleDeletesChosen(t *testing.T) {
+	tests := map[string]struct {
+		chosen       []int
+		deleteRemote bool
+		expected     []string
+	}{
+		"all":    {[]int{0, 1}, false, []string{"branch -D fix_1.2_1", "branch -D fix_1.2_2"}},
+		"one":    {[]int{1}, false, []string{"branch -D fix_1.2_2"}},
+		"none":   {nil, false, []string{}},
+		"remote": {[]int{0}, true, []string{"branch -D fix_1.2_1", "push origin --delete fix_1.2_1"}},
+	}
+	for name, test := range tests {
+		script := cleanScript()
+		ui := &gtesting.FakeUI{Choices: [][]int{test.chosen}}
+		c := Command{
+			Type:         util.FixType,
+			DeleteRemote: test.deleteRemote,
+			UI:           ui,
+			NewGit:       script.New,
+		}
+		if err := c.Handle(); err != nil {
+			t.Logf("%s: unexpected error: %v", name, err)
+			t.Fail()
+			continue
+		}
+		if len(ui.Currents) != 1 || len(ui.Currents[0]) != 2 || ui.Currents[0][0] != 0 || ui.Currents[0][1] != 1 {
+			t.Logf("%s: expected every branch preselected got %v", name, ui.Currents)
+			t.Fail()
+		}
+		got := script.CallsTo("branch", "push", "checkout")
+		if len(got) != len(test.expected) {
+			t.Logf("%s: expected %q got %q", name, test.expected, got)
+			t.Fail()
+			continue
+		}
+		for i := range got {
+			if got[i] != test.expected[i] {
+				t.Logf("%s: expected %q got %q", name, test.expected, got)
+				t.Fail()
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package finish
+
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+	"github.com/perrito666/got/git"
+	"github.com/perrito666/got/interfaces"
+	"github.com/perrito666/got/util"
+	"github.com/perrito666/got/workitem/work"
+)
+
+// The strategies to bring a work branch into its target.
+const (
+	// Merge creates a merge commit even if a fast forward is possible.
+	Merge = "merge"
+	// Squash commits all the changes of the branch as one.
+	Squash = "squash"
+	// Rebase replays the branch on top of the target and fast forwards.
+	Rebase = "rebase"
+)
+
+// Command holds the configuration and methods required for the finish
+// sub-command of any type of work branch.
+type Command struct {
+	Type         string
+	Args         []string
+	Base         string
+	Strategy     string
+	DeleteRemote bool
+	Force        bool
+	UI           interfaces.UI
+	NewGit       git.CompatibleConstructor
+}
+
+// Handle is the entry point for the Finish sub-command, it merges the
+// work branch into its target unless it already is, deletes it and
+// leaves the target checked out.
+func (f *Command) Handle() error {
+	if !f.Force {
+		status, err := git.NewRepo(f.NewGit).Status()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !status.Clean() {
+			return errors.New("cannot finish with uncommitted changes, commit or stash them first or use --force")
+		}
+	}
+	strategy, err := f.MergeStrategy()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	branch, err := f.Branch()
+	if err != nil {
+		return errors.Annotatef(err, "could not determine the %s branch to finish", f.Type)
+	}
+	// no branch chosen, user most likely hit Esc.
+	if branch == "" {
+		return nil
+	}
+	parsed, err := util.ResolveBranchName(f.NewGit, f.Type, branch)
+	if err != nil {
+		return errors.Errorf("%q is not a %s branch", branch, f.Type)
+	}
+	target := parsed.Target
+	if target == "" {
+		return errors.Errorf("%q has no target to be merged into", branch)
+	}
+
+	merged, err := util.IsMerged(f.NewGit, branch, target)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if merged {
+		fmt.Printf("%q is already merged into %q\n", branch, target)
+	} else if err := f.merge(strategy, branch, target); err != nil {
+		return errors.Annotatef(err, "cannot %s %q into %q, once solved run finish again", strategy, branch, target)
+	}
+
+	if err := f.NewGit(git.SCMDCheckout, nil).Checkout(target); err != nil {
+		return errors.Annotatef(err, "cannot switch to branch %q", target)
+	}
+	return errors.Trace(util.DeleteWorkBranch(f.NewGit, branch, target, f.DeleteRemote))
+}
+
+// merge brings branch into target following strategy, leaving target
+// checked out.
+func (f *Command) merge(strategy, branch, target string) error {
+	if strategy == Rebase {
+		if err := f.NewGit("rebase", []string{target, branch}).Run(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if err := f.NewGit(git.SCMDCheckout, nil).Checkout(target); err != nil {
+		return errors.Trace(err)
+	}
+	switch strategy {
+	case Merge:
+		return errors.Trace(f.NewGit("merge", []string{"--no-ff", branch}).Run())
+	case Squash:
+		if err := f.NewGit("merge", []string{"--squash", branch}).Run(); err != nil {
+			return errors.Trace(err)
+		}
+		return errors.Trace(f.NewGit("commit", nil).Run())
+	}
+	return errors.Trace(f.NewGit("merge", []string{"--ff-only", branch}).Run())
+}
+
+// MergeStrategy returns the strategy to finish the branch with, the one
+// passed by the user, the one configured in got.<type>.strategy or merge.
+func (f *Command) MergeStrategy() (string, error) {
+	strategy := f.Strategy
+	if strategy == "" {
+		var err error
+		if strategy, err = git.NewRepo(f.NewGit).ConfigGet("got." + f.Type + ".strategy"); err != nil {
+			return "", errors.Trace(err)
+		}
+	}
+	switch strategy {
+	case "":
+		return Merge, nil
+	case Merge, Squash, Rebase:
+		return strategy, nil
+	}
+	return "", errors.NotValidf("merge strategy %q, use merge, squash or rebase", strategy)
+}
+
+// Branch returns the work branch to finish, the one for the reference
+// passed by the user or else the current one.
+func (f *Command) Branch() (string, error) {
+	if len(f.Args) == 0 {
+		return git.NewRepo(f.NewGit).CurrentBranch()
+	}
+	w := work.Command{
+		Type:   f.Type,
+		Base:   f.Base,
+		UI:     f.UI,
+		NewGit: f.NewGit,
+	}
+	branch, err := w.Resolve(f.Args[0])
+	if err != nil || branch == nil {
+		return "", errors.Trace(err)
+	}
+	return branch.Format(), nil
+}
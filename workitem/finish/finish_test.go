@@ -0,0 +1,219 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package finish
+
+import (
+	"testing"
+
+	gtesting "github.com/perrito666/got/testing"
+	"github.com/perrito666/got/util"
+)
+
+func TestHandleUnknownReferenceFails(t *testing.T) {
+	c := Command{
+		Type:   util.FixType,
+		Args:   []string{"12345"},
+		UI:     &gtesting.FakeUI{},
+		NewGit: gtesting.New,
+	}
+	if err := c.Handle(); err == nil {
+		t.Fatal("expected an error for a bug without branches")
+	}
+}
+
+func TestMergeStrategy(t *testing.T) {
+	tests := []struct {
+		given    string
+		expected string
+		valid    bool
+	}{
+		{"", Merge, true},
+		{"squash", Squash, true},
+		{"rebase", Rebase, true},
+		{"octopus", "", false},
+	}
+	for _, test := range tests {
+		c := Command{
+			Type:     util.FixType,
+			Strategy: test.given,
+			NewGit:   gtesting.New,
+		}
+		strategy, err := c.MergeStrategy()
+		if (err == nil) != test.valid {
+			t.Logf("unexpected error for strategy %q: %v", test.given, err)
+			t.Fail()
+			continue
+		}
+		if strategy != test.expected {
+			t.Logf("expected strategy %q for %q got %q", test.expected, test.given, strategy)
+			t.Fail()
+		}
+	}
+}
+
+func TestHandleRefusesDirtyTree(t *testing.T) {
+	script := &gtesting.ScriptedGit{Responses: map[string]gtesting.Response{
+		"status --porcelain": {Output: " M a.go\x00"},
+	}}
+	c := Command{
+		Type:   util.FixType,
+		Args:   []string{"12345"},
+		UI:     &gtesting.FakeUI{},
+		NewGit: script.New,
+	}
+	if err := c.Handle(); err == nil {
+		t.Fatal("expected an error finishing with uncommitted changes")
+	}
+	if len(script.Calls) != 1 {
+		t.Fatalf("expected only the status to be checked, got %q", script.Calls)
+	}
+}
+
+// finishScript is a repository on fix_1.2_1, which tracks origin, with
+// 1.2 as the target.
+func finishScript() *gtesting.ScriptedGit {
+	script := &gtesting.ScriptedGit{Responses: map[string]gtesting.Response{
+		"rev-parse --abbrev-ref HEAD": {Output: "fix_1.2_1\n"},
+		"remote":                      {Output: "origin\n"},
+		// fix_1.2_1 is not in 1.2 and nothing like it either.
+		"merge-base --is-ancestor fix_1.2_1 1.2": {ExitCode: 1},
+		"merge-base 1.2 fix_1.2_1":               {Output: "base\n"},
+		"diff base fix_1.2_1":                    {Output: "patch"},
+		"patch-id patch":                         {Output: "fix 0000\n"},
+	}}
+	script.AddBranches(
+		[]gtesting.Branch{{Name: "1.2"}, {Name: "fix_1.2_1", Upstream: "origin/fix_1.2_1", Current: true}},
+		[]string{"origin/fix_1.2_1"},
+	)
+	return script
+}
+
+// changes are the sub-commands that change the repository when
+// finishing.
+var changes = []string{"checkout", "rebase", "merge", "commit", "branch", "push"}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestHandleStrategies(t *testing.T) {
+	tests := map[string][]string{
+		Merge: {"checkout 1.2", "merge --no-ff fix_1.2_1", "checkout 1.2", "branch -D fix_1.2_1"},
+		Squash: {"checkout 1.2", "merge --squash fix_1.2_1", "commit",
+			"checkout 1.2", "branch -D fix_1.2_1"},
+		Rebase: {"rebase 1.2 fix_1.2_1", "checkout 1.2", "merge --ff-only fix_1.2_1",
+			"checkout 1.2", "branch -D fix_1.2_1"},
+	}
+	for strategy, expected := range tests {
+		script := finishScript()
+		c := Command{
+			Type:     util.FixType,
+			Strategy: strategy,
+			UI:       &gtesting.FakeUI{},
+			NewGit:   script.New,
+		}
+		if err := c.Handle(); err != nil {
+			t.Logf("%s: unexpected error: %v", strategy, err)
+			t.Fail()
+			continue
+		}
+		if got := script.CallsTo(changes...); !equal(got, expected) {
+			t.Logf("%s: expected %q got %q", strategy, expected, got)
+			t.Fail()
+		}
+	}
+}
+
+func TestHandleForceSkipsStatus(t *testing.T) {
+	script := finishScript()
+	script.Responses["status --porcelain"] = gtesting.Response{Output: " M a.go\x00"}
+	c := Command{
+		Type:   util.FixType,
+		Force:  true,
+		UI:     &gtesting.FakeUI{},
+		NewGit: script.New,
+	}
+	if err := c.Handle(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status := script.CallsTo("status"); len(status) != 0 {
+		t.Fatalf("expected the status not to be checked, got %q", status)
+	}
+	expected := []string{"checkout 1.2", "merge --no-ff fix_1.2_1", "checkout 1.2", "branch -D fix_1.2_1"}
+	if got := script.CallsTo(changes...); !equal(got, expected) {
+		t.Fatalf("expected %q got %q", expected, got)
+	}
+}
+
+func TestHandleAlreadyMerged(t *testing.T) {
+	tests := map[string]map[string]gtesting.Response{
+		"merged": {"merge-base --is-ancestor fix_1.2_1 1.2": {}},
+		"squashed": {
+			"log -p base..1.2": {Output: "landed"},
+			"patch-id landed":  {Output: "other 0000\nfix 0000\n"},
+		},
+	}
+	for name, responses := range tests {
+		script := finishScript()
+		for key, response := range responses {
+			script.Responses[key] = response
+		}
+		c := Command{
+			Type:   util.FixType,
+			UI:     &gtesting.FakeUI{},
+			NewGit: script.New,
+		}
+		if err := c.Handle(); err != nil {
+			t.Logf("%s: unexpected error: %v", name, err)
+			t.Fail()
+			continue
+		}
+		expected := []string{"checkout 1.2", "branch -D fix_1.2_1"}
+		if got := script.CallsTo(changes...); !equal(got, expected) {
+			t.Logf("%s: expected %q got %q", name, expected, got)
+			t.Fail()
+		}
+	}
+}
+
+func TestHandleDeleteRemote(t *testing.T) {
+	tests := map[string]struct {
+		remoteMerged bool
+		expected     []string
+	}{
+		"merged": {true, []string{"checkout 1.2", "branch -D fix_1.2_1", "push origin --delete fix_1.2_1"}},
+		// someone pushed to it after the branch was fetched.
+		"not merged": {false, []string{"checkout 1.2", "branch -D fix_1.2_1"}},
+	}
+	for name, test := range tests {
+		script := finishScript()
+		script.Responses["merge-base --is-ancestor fix_1.2_1 1.2"] = gtesting.Response{}
+		if !test.remoteMerged {
+			script.Responses["merge-base --is-ancestor origin/fix_1.2_1 1.2"] = gtesting.Response{ExitCode: 1}
+		}
+		c := Command{
+			Type:         util.FixType,
+			DeleteRemote: true,
+			UI:           &gtesting.FakeUI{},
+			NewGit:       script.New,
+		}
+		if err := c.Handle(); err != nil {
+			t.Logf("%s: unexpected error: %v", name, err)
+			t.Fail()
+			continue
+		}
+		if got := script.CallsTo(changes...); !equal(got, test.expected) {
+			t.Logf("%s: expected %q got %q", name, test.expected, got)
+			t.Fail()
+		}
+	}
+}
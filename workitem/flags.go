@@ -0,0 +1,25 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package workitem
+
+import "flag"
+
+// Flags holds the flags of the sub-commands every type of work branch
+// has, the built in bug and feature included.
+type Flags struct {
+	Remote       bool
+	Strategy     string
+	DeleteRemote bool
+	Force        bool
+}
+
+// Add defines the flags in flagSet.
+func (f *Flags) Add(flagSet *flag.FlagSet) {
+	remoteDescription := "also list the branches that only exist in remotes."
+	flagSet.BoolVar(&f.Remote, "r", false, remoteDescription)
+	flagSet.BoolVar(&f.Remote, "remote", false, remoteDescription)
+	flagSet.StringVar(&f.Strategy, "strategy", "", "how finish brings the branch into its target: merge, squash or rebase.")
+	flagSet.BoolVar(&f.DeleteRemote, "delete-remote", false, "finish and clean also delete the branches in their remote.")
+	flagSet.BoolVar(&f.Force, "force", false, "finish even with uncommitted changes.")
+}
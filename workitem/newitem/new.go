@@ -0,0 +1,75 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package newitem
+
+import (
+	"github.com/juju/errors"
+	"github.com/perrito666/got/git"
+	"github.com/perrito666/got/interfaces"
+	"github.com/perrito666/got/util"
+)
+
+// Command holds the configuration and methods required for the new
+// sub-command of any type of work branch.
+type Command struct {
+	Type   string
+	Args   []string
+	Base   string
+	UI     interfaces.UI
+	NewGit git.CompatibleConstructor
+}
+
+// Handle is the entry point for the New sub-command.
+func (n *Command) Handle() error {
+	target, startPoint, err := n.Target()
+	if err != nil {
+		return errors.Annotatef(err, "could not select a target for the %s", n.Type)
+	}
+	// no target chosen, user most likely hit Esc.
+	if target == "" {
+		return nil
+	}
+
+	name := ""
+	if len(n.Args) > 0 {
+		name = n.Args[0]
+	}
+	if name == "" {
+		if name, err = n.UI.ValidatedInput(n.Type+" name: ", validateName); err != nil {
+			return errors.Annotatef(err, "could not read the %s name", n.Type)
+		}
+	}
+
+	item := util.BranchName{Type: n.Type, Target: target, Reference: util.SanitizeName(name)}
+	created, err := util.NewBranchFrom(n.NewGit, item, startPoint)
+	if err != nil {
+		return errors.Annotate(err, "cannot create new branch")
+	}
+	util.Reportf("now working in %q \n", created)
+	return nil
+}
+
+// Target returns the branch the work should target and the ref it
+// should start from, in order of precedence: the base passed by the
+// user, the configured default or the one picked interactively.
+func (n *Command) Target() (string, string, error) {
+	if n.Base != "" {
+		return n.Base, n.Base, nil
+	}
+	base, err := util.DefaultBase(n.NewGit, n.Type)
+	if err != nil {
+		return "", "", errors.Trace(err)
+	}
+	if base != "" {
+		return base, base, nil
+	}
+	return util.PickTarget(n.NewGit, n.UI)
+}
+
+func validateName(name string) error {
+	if name == "" {
+		return errors.New("the name cannot be empty")
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package newitem
+
+import (
+	"testing"
+
+	gtesting "github.com/perrito666/got/testing"
+)
+
+// newScript is a repository with master and 2.0, which is only in origin.
+func newScript() *gtesting.ScriptedGit {
+	script := &gtesting.ScriptedGit{Responses: map[string]gtesting.Response{
+		"remote": {Output: "origin\n"},
+	}}
+	script.AddBranches([]gtesting.Branch{{Name: "master", Current: true}}, []string{"origin/2.0"})
+	return script
+}
+
+func TestHandleCreatesBranch(t *testing.T) {
+	tests := map[string]struct {
+		base      string
+		args      []string
+		responses map[string]gtesting.Response
+		inputs    []string
+		choices   [][]int
+		// created is the git call creating the branch, empty for none.
+		created string
+	}{
+		"given base": {base: "master", args: []string{"bump deps"}, created: "checkout -b chore_master_bump_deps master"},
+		"default base": {
+			args:      []string{"bump deps"},
+			responses: map[string]gtesting.Response{"config --get got.chore.default": {Output: "2.0\n"}},
+			created:   "checkout -b chore_2.0_bump_deps 2.0",
+		},
+		"picked remote": {args: []string{"bump deps"}, choices: [][]int{{1}}, created: "checkout -b chore_2.0_bump_deps origin/2.0"},
+		"prompted name": {base: "master", inputs: []string{"bump deps"}, created: "checkout -b chore_master_bump_deps master"},
+		// nothing is chosen, as if the user hit Esc.
+		"no base": {args: []string{"bump deps"}},
+	}
+	for name, test := range tests {
+		script := newScript()
+		for key, response := range test.responses {
+			script.Responses[key] = response
+		}
+		ui := &gtesting.FakeUI{Inputs: test.inputs, Choices: test.choices}
+		c := Command{
+			Type:   "chore",
+			Args:   test.args,
+			Base:   test.base,
+			UI:     ui,
+			NewGit: script.New,
+		}
+		if err := c.Handle(); err != nil {
+			t.Logf("%s: unexpected error: %v", name, err)
+			t.Fail()
+			continue
+		}
+		created := script.CallsTo("checkout")
+		if test.created == "" && len(created) != 0 || test.created != "" && (len(created) != 1 || created[0] != test.created) {
+			t.Logf("%s: expected %q got %q", name, test.created, created)
+			t.Fail()
+		}
+		if len(test.inputs) > 0 && (len(ui.Prompts) != 1 || ui.Prompts[0] != "chore name: ") {
+			t.Logf("%s: expected to be prompted for the name, got %q", name, ui.Prompts)
+			t.Fail()
+		}
+	}
+}
+
+func TestHandleNoTargetChosen(t *testing.T) {
+	c := Command{
+		Type:   "chore",
+		Args:   []string{"bump"},
+		UI:     &gtesting.FakeUI{},
+		NewGit: gtesting.New,
+	}
+	if err := c.Handle(); err == nil {
+		t.Fatal("expected an error when there are no targets to pick")
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package work
+
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+	"github.com/perrito666/got/git"
+	"github.com/perrito666/got/interfaces"
+	"github.com/perrito666/got/util"
+)
+
+// Command holds the configuration and methods required for the work
+// sub-command of any type of work branch.
+type Command struct {
+	Type        string
+	Args        []string
+	Base        string
+	Interactive bool
+	Short       bool
+	// Remote includes the branches that only exist in remotes.
+	Remote bool
+	UI     interfaces.UI
+	NewGit git.CompatibleConstructor
+}
+
+// Handle is the entry point for the Work sub-command.
+func (w *Command) Handle() error {
+	if len(w.Args) == 0 {
+		if !w.Interactive {
+			return w.List()
+		}
+
+		branch, err := w.Pick()
+		if err != nil {
+			return errors.Annotatef(err, "could not select a %s to work on", w.Type)
+		}
+
+		return w.checkout(branch)
+	}
+
+	branch, err := w.Resolve(w.Args[0])
+	if err != nil {
+		return errors.Annotatef(err, "could not find a branch to work on %s %q", w.Type, w.Args[0])
+	}
+	return w.checkout(branch)
+}
+
+// checkout switches to branch, creating a local branch to track it if
+// it only exists in a remote.
+func (w *Command) checkout(branch *util.WorkBranch) error {
+	// no branch chosen, user most likely hit Esc.
+	if branch == nil {
+		return nil
+	}
+	if branch.Location == util.Remote {
+		return util.TrackBranch(w.NewGit, branch.RemoteRef)
+	}
+	if err := w.NewGit(git.SCMDCheckout, nil).Checkout(branch.Format()); err != nil {
+		return errors.Annotatef(err, "cannot switch to branch %q", branch.Format())
+	}
+	return nil
+}
+
+// Resolve returns the branch for reference, such as a bug number or a
+// feature name, which is also tried sanitized as it is when the branch is
+// created. The branch for the given base or, if none, for the default
+// one is preferred, otherwise if the work is being done for only one
+// target that branch is returned, if not the user is prompted. It
+// returns nil if none was chosen.
+func (w *Command) Resolve(reference string) (*util.WorkBranch, error) {
+	all, err := util.ListBranches(w.NewGit, w.Type, w.Remote)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	branches := all[reference]
+	if len(branches) == 0 {
+		reference = util.SanitizeName(reference)
+		branches = all[reference]
+	}
+	if len(branches) == 0 {
+		return nil, errors.NotFoundf("%s branches for %q", w.Type, reference)
+	}
+
+	base := w.Base
+	if base == "" {
+		if base, err = util.DefaultBase(w.NewGit, w.Type); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	for i, branch := range branches {
+		if base != "" && branch.Target == base {
+			return &branches[i], nil
+		}
+	}
+	if w.Base != "" {
+		return nil, errors.NotFoundf("%s branch for %q based on %q", w.Type, reference, w.Base)
+	}
+
+	if len(branches) == 1 {
+		return &branches[0], nil
+	}
+	return util.Picker(w.Type, reference, w.Remote, w.NewGit, w.UI)
+}
+
+// List prints a list of the existing work branches of the type listing
+// their reference and underneath all the target branches.
+// If short is provided, the list will show only the references, if
+// remote is, the branches that only exist in remotes are listed too.
+// For a branch to show in this list, its name must follow the
+// naming scheme of the type, by default <type>_<target>_<reference>.
+func (w *Command) List() error {
+	fmt.Printf("Available %s branches and their targets:\n", w.Type)
+	return util.PrintList(w.NewGit, w.Type, w.Short, w.Remote)
+}
+
+// Pick will prompt the user which work branch to checkout, it returns
+// nil if none was chosen.
+func (w *Command) Pick() (*util.WorkBranch, error) {
+	return util.Picker(w.Type, "", w.Remote, w.NewGit, w.UI)
+}
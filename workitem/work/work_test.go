@@ -0,0 +1,134 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package work
+
+import (
+	"testing"
+
+	gtesting "github.com/perrito666/got/testing"
+	"github.com/perrito666/got/util"
+)
+
+// workScript is a repository on fix_1.3_1 where bug 1 is being fixed
+// for 1.2 and 1.3, only the 1.2 fix is in origin.
+func workScript() *gtesting.ScriptedGit {
+	script := &gtesting.ScriptedGit{Responses: map[string]gtesting.Response{
+		"rev-parse --abbrev-ref HEAD": {Output: "fix_1.3_1\n"},
+		"remote":                      {Output: "origin\n"},
+	}}
+	script.AddBranches(
+		[]gtesting.Branch{{Name: "1.2"}, {Name: "1.3"}, {Name: "fix_1.3_1", Current: true}},
+		[]string{"origin/fix_1.2_1"},
+	)
+	return script
+}
+
+func TestHandleNoArgs(t *testing.T) {
+	tests := map[string]struct {
+		interactive bool
+		choices     [][]int
+		checkouts   []string
+	}{
+		"lists":         {false, nil, nil},
+		"picks remote":  {true, [][]int{{1}}, []string{"checkout --track origin/fix_1.2_1"}},
+		"picks local":   {true, [][]int{{0}}, []string{"checkout fix_1.3_1"}},
+		"picks nothing": {true, nil, nil},
+	}
+	for name, test := range tests {
+		script := workScript()
+		ui := &gtesting.FakeUI{Choices: test.choices}
+		c := Command{
+			Type:        util.FixType,
+			Args:        []string{},
+			Interactive: test.interactive,
+			Remote:      true,
+			UI:          ui,
+			NewGit:      script.New,
+		}
+		if err := c.Handle(); err != nil {
+			t.Logf("%s: unexpected error: %v", name, err)
+			t.Fail()
+			continue
+		}
+		checkouts := script.CallsTo("checkout")
+		if len(checkouts) != len(test.checkouts) || len(checkouts) > 0 && checkouts[0] != test.checkouts[0] {
+			t.Logf("%s: expected %q got %q", name, test.checkouts, checkouts)
+			t.Fail()
+		}
+		if !test.interactive {
+			if len(ui.Currents) != 0 {
+				t.Logf("%s: expected no menu got %d", name, len(ui.Currents))
+				t.Fail()
+			}
+			continue
+		}
+		// local branches come first and the one checked out is preselected.
+		if len(ui.Currents) != 1 || len(ui.Currents[0]) != 1 || ui.Currents[0][0] != 0 {
+			t.Logf("%s: expected fix_1.3_1 preselected got %v", name, ui.Currents)
+			t.Fail()
+		}
+	}
+}
+
+func TestHandleResolvesTarget(t *testing.T) {
+	tests := map[string]struct {
+		base      string
+		responses map[string]gtesting.Response
+		choices   [][]int
+		checkout  string
+	}{
+		"base":    {"1.2", nil, nil, "checkout --track origin/fix_1.2_1"},
+		"default": {"", map[string]gtesting.Response{"config --get got.fix.default": {Output: "1.3\n"}}, nil, "checkout fix_1.3_1"},
+		"picked":  {"", nil, [][]int{{1}}, "checkout --track origin/fix_1.2_1"},
+	}
+	for name, test := range tests {
+		script := workScript()
+		for key, response := range test.responses {
+			script.Responses[key] = response
+		}
+		c := Command{
+			Type:   util.FixType,
+			Args:   []string{"1"},
+			Base:   test.base,
+			Remote: true,
+			UI:     &gtesting.FakeUI{Choices: test.choices},
+			NewGit: script.New,
+		}
+		if err := c.Handle(); err != nil {
+			t.Logf("%s: unexpected error: %v", name, err)
+			t.Fail()
+			continue
+		}
+		if checkouts := script.CallsTo("checkout"); len(checkouts) != 1 || checkouts[0] != test.checkout {
+			t.Logf("%s: expected %q got %q", name, test.checkout, checkouts)
+			t.Fail()
+		}
+	}
+}
+
+func TestHandleUnknownBugFails(t *testing.T) {
+	c := Command{
+		Type:        util.FixType,
+		Args:        []string{"12345"},
+		Interactive: false,
+		Short:       false,
+		UI:          &gtesting.FakeUI{},
+		NewGit:      gtesting.New,
+	}
+	if err := c.Handle(); err == nil {
+		t.Fatal("expected an error for a bug without branches")
+	}
+}
+
+func TestHandleUnknownFeatureFails(t *testing.T) {
+	c := Command{
+		Type:   util.FeatureType,
+		Args:   []string{"login flow"},
+		UI:     &gtesting.FakeUI{},
+		NewGit: gtesting.New,
+	}
+	if err := c.Handle(); err == nil {
+		t.Fatal("expected an error for a feature without branches")
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+// Package workitem provides the sub-commands shared by all the types
+// of work branch and the got commands for the types declared in the
+// repository configuration, such as hotfix, chore or spike.
+package workitem
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/perrito666/got/cli"
+	"github.com/perrito666/got/git"
+	"github.com/perrito666/got/registry"
+	"github.com/perrito666/got/util"
+	"github.com/perrito666/got/workitem/clean"
+	"github.com/perrito666/got/workitem/finish"
+	"github.com/perrito666/got/workitem/newitem"
+	"github.com/perrito666/got/workitem/work"
+)
+
+// gitCommands are the git commands a work branch type cannot be named
+// after, got would hide them when passing calls through to git.
+var gitCommands = []string{
+	"add", "am", "apply", "archive", "bisect", "blame", "branch", "bundle",
+	"checkout", "cherry", "cherry-pick", "clean", "clone", "commit", "config",
+	"describe", "diff", "fetch", "format-patch", "gc", "grep", "help", "init",
+	"log", "merge", "mv", "notes", "pull", "push", "range-diff", "rebase",
+	"reflog", "remote", "reset", "restore", "revert", "rm", "shortlog", "show",
+	"sparse-checkout", "stash", "status", "submodule", "switch", "tag",
+	"worktree",
+}
+
+// Register adds a got command for each of the types of work branch
+// declared in the repository, the built in ones have their own. Types
+// that cannot have a command are reported once all the others are added.
+func Register(newGit git.CompatibleConstructor) error {
+	types, err := util.WorkTypes(newGit)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	commands := registry.Commands()
+	problems := []string{}
+	for _, branchType := range types {
+		if branchType == util.FixType || branchType == util.FeatureType {
+			continue
+		}
+		if err := (util.BranchName{Type: branchType, Reference: "x"}).Validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("%q is not a valid branch type", branchType))
+			continue
+		}
+		if _, ok := commands[branchType]; ok {
+			problems = append(problems, fmt.Sprintf("%q is already a got command", branchType))
+			continue
+		}
+		if isGitCommand(branchType) {
+			problems = append(problems, fmt.Sprintf("%q would hide the git command", branchType))
+			continue
+		}
+		if err := registry.RegisterNewCommand(branchType, NewCommand(branchType)); err != nil {
+			return errors.Annotatef(err, "cannot add command for work branch type %q", branchType)
+		}
+	}
+	if len(problems) > 0 {
+		return errors.Errorf("cannot add commands for work branch types: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+func isGitCommand(name string) bool {
+	for _, command := range gitCommands {
+		if command == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NewCommand returns the constructor for the command of the given type
+// of work branch.
+func NewCommand(branchType string) registry.CommandConstructor {
+	return func() (registry.Command, error) {
+		c := &Command{Type: branchType}
+		c.flagSet = flag.NewFlagSet(branchType, flag.ExitOnError)
+		shortDescription := "show only the names omitting the target branches"
+		c.flagSet.BoolVar(&c.short, "s", false, shortDescription)
+		c.flagSet.BoolVar(&c.short, "short", false, shortDescription)
+		c.flagSet.BoolVar(&c.interactive, "i", false, "prompt the branch with a menu.")
+		baseDescription := "the maintenance branch to use as target."
+		c.flagSet.StringVar(&c.base, "b", "", baseDescription)
+		c.flagSet.StringVar(&c.base, "base", "", baseDescription)
+		c.Flags.Add(c.flagSet)
+		return c, nil
+	}
+}
+
+var usageDoc = `
+usage: 
+got %[1]s: prints this help
+
+%[1]s is a type of work branch declared in the repository configuration,
+either with "git config --add got.type %[1]s" or under types in .got.yml.
+
+%[1]s available subcommands:
+
+  new [-b target_branch] [name]
+    will create a new %[1]s branch for the target, if no target is given the
+    default (got.%[1]s.default git config key) is used or you will be prompted.
+
+  work [-s|--short] [-r|--remote]
+  list [-s|--short] [-r|--remote]
+    will list the %[1]s branches and the targets for which they exist, with -r
+    including the ones that only exist in remotes.
+
+  work [-i] [-r|--remote] [-b target_branch] <name>
+    will checkout the %[1]s branch, prompting if there is more than one, with -r
+    a local branch is created to track one that only exists in a remote.
+
+  finish [--strategy merge|squash|rebase] [--delete-remote] [--force] [-b target_branch] [name]
+    will merge the %[1]s branch, or the current one, into its target unless it
+    already is, delete it and checkout the target. the strategy can also be set
+    in the got.%[1]s.strategy git config key, merge is used by default.
+    with --delete-remote the branch is deleted from its remote too. it refuses
+    to run with uncommitted changes unless --force is given.
+
+  clean [--delete-remote]
+    will offer to delete the %[1]s branches already merged into their targets,
+    with --delete-remote they are deleted from their remote too.
+
+`
+
+// Command provides the sub-commands for a type of work branch.
+type Command struct {
+	Type string
+
+	flagSet     *flag.FlagSet
+	short       bool
+	interactive bool
+	base        string
+
+	Flags
+}
+
+// Run implements registry.Command
+func (c *Command) Run(args []string) error {
+	if len(args) == 0 {
+		fmt.Printf(usageDoc, c.Type)
+		return nil
+	}
+	subC := args[0]
+	subArgs := args[1:]
+	if err := c.flagSet.Parse(subArgs); err != nil {
+		return errors.Annotate(err, "error parsing arguments")
+	}
+	switch subC {
+	case "new":
+		n := newitem.Command{
+			Type:   c.Type,
+			Args:   c.flagSet.Args(),
+			Base:   c.base,
+			UI:     cli.New(),
+			NewGit: git.Backend,
+		}
+		return n.Handle()
+	case "work", "list":
+		w := work.Command{
+			Type:        c.Type,
+			Args:        c.flagSet.Args(),
+			Base:        c.base,
+			Interactive: c.interactive,
+			Short:       c.short,
+			Remote:      c.Remote,
+			UI:          cli.New(),
+			NewGit:      git.Backend,
+		}
+		if subC == "list" {
+			return w.List()
+		}
+		return w.Handle()
+	case "clean":
+		c := clean.Command{
+			Type:         c.Type,
+			DeleteRemote: c.DeleteRemote,
+			UI:           cli.New(),
+			NewGit:       git.Backend,
+		}
+		return c.Handle()
+	case "finish":
+		f := finish.Command{
+			Type:         c.Type,
+			Args:         c.flagSet.Args(),
+			Base:         c.base,
+			Strategy:     c.Strategy,
+			DeleteRemote: c.DeleteRemote,
+			Force:        c.Force,
+			UI:           cli.New(),
+			NewGit:       git.Backend,
+		}
+		return f.Handle()
+	}
+	return errors.NotSupportedf("%s sub-command %q", c.Type, subC)
+}
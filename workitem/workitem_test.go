@@ -0,0 +1,76 @@
+// Copyright 2015 Horacio Duran.
+// Licenced under the MIT license, see LICENCE file for details.
+
+package workitem
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/perrito666/got/registry"
+	gtesting "github.com/perrito666/got/testing"
+)
+
+func TestNewCommandParsesFlags(t *testing.T) {
+	var _ registry.Command = &Command{}
+	command, err := NewCommand("chore")()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := command.(*Command)
+	if c.Type != "chore" {
+		t.Fatalf("expected type %q got %q", "chore", c.Type)
+	}
+	if err := c.flagSet.Parse([]string{"-b", "1.2", "-s", "deps"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.base != "1.2" || !c.short {
+		t.Fatalf("flags not parsed, got base %q short %v", c.base, c.short)
+	}
+}
+
+func TestRunUnknownSubCommandFails(t *testing.T) {
+	command, err := NewCommand("spike")()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := command.Run([]string{"explode"}); err == nil {
+		t.Fatal("expected an error for an unknown sub-command")
+	}
+}
+
+func TestRegisterReportsClashes(t *testing.T) {
+	if _, ok := registry.Commands()["bug"]; !ok {
+		if err := registry.RegisterNewCommand("bug", NewCommand("bug")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	script := &gtesting.ScriptedGit{Responses: map[string]gtesting.Response{
+		"config --get-all got.type": {Output: "bug\nhotfix\nstatus\nsp ike\nzchore\n"},
+	}}
+	err := Register(script.New)
+	if err == nil {
+		t.Fatal("expected the clashing types to be reported")
+	}
+	for _, expected := range []string{`"bug" is already a got command`, `"status" would hide the git command`, `"sp ike" is not a valid`} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Logf("expected %q in %q", expected, err)
+			t.Fail()
+		}
+	}
+	if strings.Contains(err.Error(), "0x") {
+		t.Logf("expected clashes to be reported by name, got %q", err)
+		t.Fail()
+	}
+	commands := registry.Commands()
+	for _, branchType := range []string{"hotfix", "zchore"} {
+		if _, ok := commands[branchType]; !ok {
+			t.Logf("expected %q to be registered despite the clashes", branchType)
+			t.Fail()
+		}
+	}
+	if _, ok := commands["status"]; ok {
+		t.Logf("expected status to be left to git")
+		t.Fail()
+	}
+}